@@ -0,0 +1,286 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"fmt"
+)
+
+// KitLogger has the method set of the go-kit log.Logger interface.
+type KitLogger interface {
+	Log(keyvals ...interface{}) error
+}
+
+// NewKitLogger returns a go-kit logger writing to l.
+//
+// The level set with the go-kit level package under the "level" key is
+// the level of the entry, Info if there is none. The "msg" value is the
+// message, the "caller" value set with log.Caller replaces the source
+// field and "ts" is dropped as entries carry their own time. The other
+// pairs are added as fields.
+func NewKitLogger(l Logger) KitLogger {
+	return kitLogger{loggerOf(l)}
+}
+
+type kitLogger struct {
+	l logger
+}
+
+func (k kitLogger) Log(keyvals ...interface{}) error {
+	level := InfoLevel
+	var msg string
+	fields := sweeten(keyvals)
+	kept := fields[:0]
+	for _, f := range fields {
+		switch f.Key {
+		case "level":
+			if parsed, err := ParseLevel(fmt.Sprint(f.iface)); err == nil {
+				level = parsed
+				continue
+			}
+		case "msg":
+			msg = fmt.Sprint(f.iface)
+			continue
+		case "caller":
+			f.iface = fmt.Sprint(f.iface)
+			f.Key = "source"
+		case "ts":
+			continue
+		}
+		kept = append(kept, f)
+	}
+	if k.l.IsEnabled(level) {
+		k.l.log(level, msg, kept)
+	}
+	return nil
+}
+
+// LogrAdapter has the logging methods of a logr.LogSink and writes the
+// entries of a logr.Logger. The V-levels map to Info for 0, Debug for 1
+// and Trace above, names are appended with Named.
+//
+// logr is not a dependency of this package, so LogrAdapter is not a
+// logr.LogSink: it has no Init method, which takes a logr.RuntimeInfo,
+// and WithValues, WithName and WithCallDepth return a *LogrAdapter
+// instead of a logr.LogSink. The logr.LogSink and logr.CallDepthLogSink
+// are implemented on top of it by the program that imports logr:
+//
+//	type logrSink struct{ *log.LogrAdapter }
+//
+//	func (s *logrSink) Init(info logr.RuntimeInfo) { s.LogrAdapter = s.LogrAdapter.WithCallDepth(info.CallDepth) }
+//	func (s *logrSink) WithValues(kv ...interface{}) logr.LogSink { return &logrSink{s.LogrAdapter.WithValues(kv...)} }
+//	func (s *logrSink) WithName(name string) logr.LogSink { return &logrSink{s.LogrAdapter.WithName(name)} }
+//	func (s *logrSink) WithCallDepth(depth int) logr.LogSink { return &logrSink{s.LogrAdapter.WithCallDepth(depth)} }
+//
+//	logger := logr.New(&logrSink{log.NewLogrAdapter(log.Base())})
+//
+// logr.FromSlogHandler(log.SlogHandler()) is an alternative for logr
+// 1.3 and above, its V-levels follow the slog levels.
+type LogrAdapter struct {
+	l logger
+}
+
+// NewLogrAdapter returns a LogrAdapter writing to l.
+func NewLogrAdapter(l Logger) *LogrAdapter {
+	return &LogrAdapter{loggerOf(l)}
+}
+
+// logrLevel returns the level of the logr V-level v.
+func logrLevel(v int) Level {
+	switch {
+	case v <= 0:
+		return InfoLevel
+	case v == 1:
+		return DebugLevel
+	}
+	return TraceLevel
+}
+
+// Enabled reports whether entries at the V-level v are logged.
+func (s *LogrAdapter) Enabled(v int) bool {
+	return s.l.IsEnabled(logrLevel(v))
+}
+
+// Info logs msg at the V-level v.
+func (s *LogrAdapter) Info(v int, msg string, keysAndValues ...interface{}) {
+	if level := logrLevel(v); s.l.IsEnabled(level) {
+		s.l.log(level, msg, sweeten(keysAndValues))
+	}
+}
+
+// Error logs msg and err at level Error.
+func (s *LogrAdapter) Error(err error, msg string, keysAndValues ...interface{}) {
+	if s.l.IsEnabled(ErrorLevel) {
+		l := s.l
+		if err != nil {
+			l.entry = l.entry.WithFields(errorFields(err))
+		}
+		l.log(ErrorLevel, msg, sweeten(keysAndValues))
+	}
+}
+
+// WithValues returns a sink adding the keys and values to every entry.
+func (s *LogrAdapter) WithValues(keysAndValues ...interface{}) *LogrAdapter {
+	fields := sweeten(keysAndValues)
+	data := make(map[string]interface{}, len(fields))
+	for _, f := range fields {
+		data[f.Key] = f.dataValue()
+	}
+	return &LogrAdapter{s.l.WithFields(data).(logger)}
+}
+
+// WithName returns a sink for the named logger below the name of s.
+func (s *LogrAdapter) WithName(name string) *LogrAdapter {
+	return &LogrAdapter{s.l.Named(name).(logger)}
+}
+
+// WithCallDepth returns a sink reporting the source depth frames above
+// the caller of its methods.
+func (s *LogrAdapter) WithCallDepth(depth int) *LogrAdapter {
+	return &LogrAdapter{s.l.WithCallerSkip(depth).(logger)}
+}
+
+// GrpcLogger implements the grpclog.LoggerV2 and grpclog.DepthLoggerV2
+// interfaces. It is installed with
+//
+//	grpclog.SetLoggerV2(log.NewGrpcLogger(log.Named("grpc")))
+//
+// The verbosity levels of V map to Info for 0, Debug for 1 and Trace
+// above.
+type GrpcLogger struct {
+	l logger
+}
+
+// NewGrpcLogger returns a GrpcLogger writing to l.
+func NewGrpcLogger(l Logger) *GrpcLogger {
+	return &GrpcLogger{loggerOf(l)}
+}
+
+func (g *GrpcLogger) Info(args ...interface{}) {
+	if g.l.IsEnabled(InfoLevel) {
+		g.l.log(InfoLevel, fmt.Sprint(args...), nil)
+	}
+}
+
+func (g *GrpcLogger) Infoln(args ...interface{}) {
+	if g.l.IsEnabled(InfoLevel) {
+		g.l.log(InfoLevel, sprintln(args...), nil)
+	}
+}
+
+func (g *GrpcLogger) Infof(format string, args ...interface{}) {
+	if g.l.IsEnabled(InfoLevel) {
+		g.l.logf(InfoLevel, format, args)
+	}
+}
+
+func (g *GrpcLogger) Warning(args ...interface{}) {
+	if g.l.IsEnabled(WarnLevel) {
+		g.l.log(WarnLevel, fmt.Sprint(args...), nil)
+	}
+}
+
+func (g *GrpcLogger) Warningln(args ...interface{}) {
+	if g.l.IsEnabled(WarnLevel) {
+		g.l.log(WarnLevel, sprintln(args...), nil)
+	}
+}
+
+func (g *GrpcLogger) Warningf(format string, args ...interface{}) {
+	if g.l.IsEnabled(WarnLevel) {
+		g.l.logf(WarnLevel, format, args)
+	}
+}
+
+func (g *GrpcLogger) Error(args ...interface{}) {
+	if g.l.IsEnabled(ErrorLevel) {
+		g.l.log(ErrorLevel, fmt.Sprint(args...), nil)
+	}
+}
+
+func (g *GrpcLogger) Errorln(args ...interface{}) {
+	if g.l.IsEnabled(ErrorLevel) {
+		g.l.log(ErrorLevel, sprintln(args...), nil)
+	}
+}
+
+func (g *GrpcLogger) Errorf(format string, args ...interface{}) {
+	if g.l.IsEnabled(ErrorLevel) {
+		g.l.logf(ErrorLevel, format, args)
+	}
+}
+
+func (g *GrpcLogger) Fatal(args ...interface{}) {
+	if g.l.IsEnabled(FatalLevel) {
+		g.l.log(FatalLevel, fmt.Sprint(args...), nil)
+	} else {
+		g.l.core.fatalExit()
+	}
+}
+
+func (g *GrpcLogger) Fatalln(args ...interface{}) {
+	if g.l.IsEnabled(FatalLevel) {
+		g.l.log(FatalLevel, sprintln(args...), nil)
+	} else {
+		g.l.core.fatalExit()
+	}
+}
+
+func (g *GrpcLogger) Fatalf(format string, args ...interface{}) {
+	if g.l.IsEnabled(FatalLevel) {
+		g.l.logf(FatalLevel, format, args)
+	} else {
+		g.l.core.fatalExit()
+	}
+}
+
+// V reports whether the verbosity level v is enabled.
+func (g *GrpcLogger) V(v int) bool {
+	return g.l.IsEnabled(logrLevel(v))
+}
+
+// InfoDepth logs args like fmt.Println at level Info, reporting the
+// source depth frames above its caller.
+func (g *GrpcLogger) InfoDepth(depth int, args ...interface{}) {
+	g.logDepth(InfoLevel, depth, args)
+}
+
+// WarningDepth logs args like fmt.Println at level Warn, reporting the
+// source depth frames above its caller.
+func (g *GrpcLogger) WarningDepth(depth int, args ...interface{}) {
+	g.logDepth(WarnLevel, depth, args)
+}
+
+// ErrorDepth logs args like fmt.Println at level Error, reporting the
+// source depth frames above its caller.
+func (g *GrpcLogger) ErrorDepth(depth int, args ...interface{}) {
+	g.logDepth(ErrorLevel, depth, args)
+}
+
+// FatalDepth logs args like fmt.Println at level Fatal, reporting the
+// source depth frames above its caller.
+func (g *GrpcLogger) FatalDepth(depth int, args ...interface{}) {
+	g.logDepth(FatalLevel, depth, args)
+}
+
+func (g *GrpcLogger) logDepth(level Level, depth int, args []interface{}) {
+	l := g.l
+	if l.IsEnabled(level) {
+		// skip logDepth itself
+		l.skip += depth + 1
+		l.log(level, sprintln(args...), nil)
+	} else if level == FatalLevel {
+		l.core.fatalExit()
+	}
+}
@@ -0,0 +1,173 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// kitLevel mimics the values of the go-kit level package.
+type kitLevel string
+
+func (l kitLevel) String() string { return string(l) }
+
+// otherLogger is an implementation of Logger that is not a logger.
+type otherLogger struct {
+	Logger
+}
+
+func TestAdaptersOtherLogger(t *testing.T) {
+	var buf bytes.Buffer
+	l := otherLogger{NewLogger(&buf)}
+
+	NewKitLogger(l).Log("msg", "kit")
+	NewLogrAdapter(l).Info(0, "logr")
+	NewLogrAdapter(l).Info(1, "disabled")
+	NewGrpcLogger(l).Warning("grpc")
+	out := buf.String()
+	for _, want := range []string{"msg=kit", "msg=logr", "msg=grpc"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("%s not found in %q", want, out)
+		}
+	}
+	if strings.Contains(out, "disabled") {
+		t.Fatalf("disabled entry forwarded in %q", out)
+	}
+}
+
+func TestKitLogger(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLogger(&buf)
+	kl := NewKitLogger(l)
+
+	kl.Log("level", kitLevel("warn"), "ts", "2006-01-02", "caller", "discovery.go:42", "msg", "target down", "target", "node1")
+	out := buf.String()
+	for _, want := range []string{"level=warning", `msg="target down"`, "target=node1", `source="discovery.go:42"`} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("%s not found in %q", want, out)
+		}
+	}
+	if strings.Contains(out, "ts=") {
+		t.Fatalf("ts not dropped in %q", out)
+	}
+
+	buf.Reset()
+	kl.Log("level", kitLevel("debug"), "msg", "disabled")
+	source := nextLine()
+	kl.Log("event", "started")
+	if out := buf.String(); strings.Contains(out, "disabled") || !strings.Contains(out, "level=info") || !strings.Contains(out, `source="`+source+`"`) {
+		t.Fatalf("unexpected output %q", out)
+	}
+}
+
+func TestLogrAdapter(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLogger(&buf)
+	l.(logger).core.setLevel(DebugLevel)
+	s := NewLogrAdapter(l).WithName("controller").WithValues("pod", "web-0")
+
+	if !s.Enabled(1) || s.Enabled(2) {
+		t.Fatal("unexpected V-level mapping")
+	}
+	source := nextLine()
+	s.Info(1, "reconciling", "attempt", 2)
+	s.Info(2, "too verbose")
+	s.Error(errors.New("conflict"), "update failed")
+	out := buf.String()
+	for _, want := range []string{"level=debug", "msg=reconciling", "attempt=2", "pod=web-0", "logger=controller", "level=error", "error=conflict"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("%s not found in %q", want, out)
+		}
+	}
+	if strings.Contains(out, "too verbose") {
+		t.Fatalf("V(2) entry written in %q", out)
+	}
+	if !strings.Contains(out, `source="`+source+`"`) {
+		t.Fatalf("unexpected source in %q", out)
+	}
+}
+
+// grpcLoggerV2 and grpcDepthLoggerV2 have the method sets of the grpclog
+// interfaces.
+type grpcLoggerV2 interface {
+	Info(args ...interface{})
+	Infoln(args ...interface{})
+	Infof(format string, args ...interface{})
+	Warning(args ...interface{})
+	Warningln(args ...interface{})
+	Warningf(format string, args ...interface{})
+	Error(args ...interface{})
+	Errorln(args ...interface{})
+	Errorf(format string, args ...interface{})
+	Fatal(args ...interface{})
+	Fatalln(args ...interface{})
+	Fatalf(format string, args ...interface{})
+	V(l int) bool
+}
+
+type grpcDepthLoggerV2 interface {
+	grpcLoggerV2
+	InfoDepth(depth int, args ...interface{})
+	WarningDepth(depth int, args ...interface{})
+	ErrorDepth(depth int, args ...interface{})
+	FatalDepth(depth int, args ...interface{})
+}
+
+var _ grpcDepthLoggerV2 = (*GrpcLogger)(nil)
+
+func grpcWarning(g grpcDepthLoggerV2, args ...interface{}) {
+	g.WarningDepth(1, args...)
+}
+
+func TestGrpcLogger(t *testing.T) {
+	var buf bytes.Buffer
+	g := NewGrpcLogger(NewLogger(&buf).Named("grpc"))
+
+	if !g.V(0) || g.V(1) {
+		t.Fatal("unexpected verbosity mapping")
+	}
+	g.Infof("channel %d created", 1)
+	g.Info("info")
+	source := nextLine()
+	grpcWarning(g, "transport", "closing")
+	out := buf.String()
+	for _, want := range []string{`msg="channel 1 created"`, "logger=grpc", `msg="transport closing"`, `source="` + source + `"`} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("%s not found in %q", want, out)
+		}
+	}
+}
+
+func TestGrpcLoggerFatalExitsWhenDisabled(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLogger(&buf)
+	l.SetLevel(PanicLevel)
+	var codes []int
+	l.SetExitFunc(func(code int) { codes = append(codes, code) })
+	g := NewGrpcLogger(l)
+
+	g.Fatal("fatal")
+	g.Fatalln("fatalln")
+	g.Fatalf("%s", "fatalf")
+	g.FatalDepth(0, "fatal depth")
+	if len(codes) != 4 || codes[0] != 1 {
+		t.Fatalf("unexpected exit codes %v", codes)
+	}
+	if buf.Len() != 0 {
+		t.Fatalf("disabled fatal entries written: %q", buf.String())
+	}
+}
@@ -0,0 +1,246 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/sirupsen/logrus"
+)
+
+// OverflowPolicy selects what the async dispatcher does with an entry
+// logged while its queue is full.
+type OverflowPolicy int
+
+const (
+	// OverflowBlock makes the caller wait for room in the queue.
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropNewest drops the entry being logged.
+	OverflowDropNewest
+	// OverflowDropOldest drops the oldest queued entry to make room.
+	OverflowDropOldest
+	// OverflowDropBelow drops the entry being logged if it is less severe
+	// than AsyncOptions.MinLevel and blocks otherwise.
+	OverflowDropBelow
+)
+
+// DefaultAsyncQueueSize is the queue size used when AsyncOptions.Size is
+// not set.
+const DefaultAsyncQueueSize = 1024
+
+// AsyncOptions configures the async dispatcher enabled by SetAsync.
+type AsyncOptions struct {
+	// Size is the number of entries the queue holds.
+	Size int
+	// Overflow is the policy applied when the queue is full.
+	Overflow OverflowPolicy
+	// MinLevel is the least severe level that is never dropped with
+	// OverflowDropBelow.
+	MinLevel Level
+}
+
+// AsyncStats describes the state of the async dispatcher.
+type AsyncStats struct {
+	Enabled bool   `json:"enabled"`
+	Queued  int    `json:"queued"`
+	Size    int    `json:"size"`
+	Dropped uint64 `json:"dropped"`
+}
+
+// SetAsync enables the async dispatcher of the standard logger, see
+// Logger.SetAsync.
+func SetAsync(opts *AsyncOptions) {
+	baseLogger.SetAsync(opts)
+}
+
+// GetAsyncStats returns the state of the async dispatcher of the standard
+// logger.
+func GetAsyncStats() AsyncStats {
+	return baseLogger.AsyncStats()
+}
+
+// SetAsync makes the logger queue the entries and write them and fire the
+// hooks from a background goroutine, so that slow outputs and hooks do not
+// delay the callers. Panic and Fatal entries are written synchronously
+// after the entries queued before them. Shutdown waits for the queue to
+// be drained.
+//
+// A nil opts drains the queue and makes the logger synchronous again.
+func (l logger) SetAsync(opts *AsyncOptions) {
+	l.core.asyncMu.Lock()
+	defer l.core.asyncMu.Unlock()
+
+	var d *dispatcher
+	if opts != nil {
+		d = newDispatcher(l.core, *opts)
+	}
+	old, _ := l.core.async.Load().(*dispatcher)
+	l.core.async.Store(d)
+	if old != nil {
+		old.close()
+	}
+}
+
+// AsyncStats returns the state of the async dispatcher of the logger.
+func (l logger) AsyncStats() AsyncStats {
+	d, _ := l.core.async.Load().(*dispatcher)
+	if d == nil {
+		return AsyncStats{}
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return AsyncStats{Enabled: true, Queued: d.n, Size: len(d.ring), Dropped: atomic.LoadUint64(&d.dropped)}
+}
+
+// dispatcher writes the entries of a core from a single goroutine, in the
+// order they were queued.
+type dispatcher struct {
+	dropped uint64
+
+	c    *core
+	opts AsyncOptions
+
+	mu sync.Mutex
+	// ring holds n entries starting at head
+	ring     []*logrus.Entry
+	head, n  int
+	busy     bool
+	closed   bool
+	notEmpty *sync.Cond
+	notFull  *sync.Cond
+	// idle is closed and replaced when the queue becomes empty
+	idle chan struct{}
+	done chan struct{}
+}
+
+func newDispatcher(c *core, opts AsyncOptions) *dispatcher {
+	if opts.Size <= 0 {
+		opts.Size = DefaultAsyncQueueSize
+	}
+	d := &dispatcher{
+		c:    c,
+		opts: opts,
+		ring: make([]*logrus.Entry, opts.Size),
+		idle: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	d.notEmpty = sync.NewCond(&d.mu)
+	d.notFull = sync.NewCond(&d.mu)
+	go d.run()
+	return d
+}
+
+// enqueue queues the entry according to the overflow policy. It returns
+// false if the dispatcher is closed and the entry must be written by the
+// caller.
+func (d *dispatcher) enqueue(entry *logrus.Entry) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for !d.closed && d.n == len(d.ring) {
+		switch {
+		case d.opts.Overflow == OverflowDropNewest,
+			d.opts.Overflow == OverflowDropBelow && Level(entry.Level) > d.opts.MinLevel:
+			atomic.AddUint64(&d.dropped, 1)
+			return true
+		case d.opts.Overflow == OverflowDropOldest:
+			d.ring[d.head] = nil
+			d.head = (d.head + 1) % len(d.ring)
+			d.n--
+			atomic.AddUint64(&d.dropped, 1)
+		default:
+			d.notFull.Wait()
+		}
+	}
+	if d.closed {
+		return false
+	}
+	d.ring[(d.head+d.n)%len(d.ring)] = entry
+	d.n++
+	d.notEmpty.Signal()
+	return true
+}
+
+func (d *dispatcher) run() {
+	defer close(d.done)
+
+	d.mu.Lock()
+	for {
+		for d.n == 0 && !d.closed {
+			d.notEmpty.Wait()
+		}
+		if d.n == 0 {
+			d.mu.Unlock()
+			return
+		}
+		entry := d.ring[d.head]
+		d.ring[d.head] = nil
+		d.head = (d.head + 1) % len(d.ring)
+		d.n--
+		d.busy = true
+		d.notFull.Signal()
+		d.mu.Unlock()
+
+		d.c.output(entry)
+
+		d.mu.Lock()
+		d.busy = false
+		if d.n == 0 {
+			close(d.idle)
+			d.idle = make(chan struct{})
+		}
+	}
+}
+
+// drain waits until the entries queued so far are written or ctx expires.
+func (d *dispatcher) drain(ctx context.Context) error {
+	d.mu.Lock()
+	if d.n == 0 && !d.busy {
+		d.mu.Unlock()
+		return nil
+	}
+	idle := d.idle
+	d.mu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-d.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// close writes the queued entries and stops the dispatcher, later entries
+// are written by their callers.
+func (d *dispatcher) close() {
+	d.mu.Lock()
+	d.closed = true
+	d.notEmpty.Broadcast()
+	d.notFull.Broadcast()
+	d.mu.Unlock()
+	<-d.done
+}
+
+// drainAsync waits for the queue of the async dispatcher, it is called on
+// shutdown.
+func (c *core) drainAsync(ctx context.Context) error {
+	if d, _ := c.async.Load().(*dispatcher); d != nil {
+		return d.drain(ctx)
+	}
+	return nil
+}
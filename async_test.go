@@ -0,0 +1,160 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// blockingHook blocks every entry until release is closed.
+type blockingHook struct {
+	countHook
+	release chan struct{}
+}
+
+func (h *blockingHook) Fire(entry *logrus.Entry) error {
+	<-h.release
+	return h.countHook.Fire(entry)
+}
+
+func TestAsync(t *testing.T) {
+	var buf syncBuffer
+	l := NewLogger(&buf)
+	hook := &blockingHook{release: make(chan struct{})}
+	if _, err := l.AddHook(hook, InfoLevel); err != nil {
+		t.Fatal(err)
+	}
+	l.SetAsync(&AsyncOptions{Size: 4})
+
+	// the caller is not delayed by the blocked hook
+	for i := 0; i < 3; i++ {
+		l.Infof("entry %d", i)
+	}
+	if st := l.AsyncStats(); !st.Enabled || st.Size != 4 {
+		t.Fatalf("unexpected stats %+v", st)
+	}
+	if buf.String() != "" {
+		t.Fatalf("entries written before the hook returned: %q", buf.String())
+	}
+
+	close(hook.release)
+	if err := l.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if hook.count() != 3 || strings.Index(out, "entry 0") > strings.Index(out, "entry 2") {
+		t.Fatalf("unexpected output %q", out)
+	}
+
+	l.SetAsync(nil)
+	l.Info("sync")
+	if !strings.Contains(buf.String(), "msg=sync") {
+		t.Fatalf("entry not written synchronously in %q", buf.String())
+	}
+}
+
+func TestAsyncOverflow(t *testing.T) {
+	for _, tc := range []struct {
+		policy OverflowPolicy
+		want   []string
+	}{
+		{OverflowDropNewest, []string{"first", "a", "b"}},
+		{OverflowDropOldest, []string{"first", "c", "d"}},
+		{OverflowDropBelow, []string{"first", "a", "b", "d"}},
+	} {
+		var buf syncBuffer
+		l := NewLogger(&buf)
+		hook := &blockingHook{release: make(chan struct{})}
+		if _, err := l.AddHook(hook, InfoLevel); err != nil {
+			t.Fatal(err)
+		}
+		l.SetAsync(&AsyncOptions{Size: 2, Overflow: tc.policy, MinLevel: WarnLevel})
+
+		l.Info("first")
+		// wait for the dispatcher to block on the first entry
+		waitFor(t, func() bool { return l.AsyncStats().Queued == 0 })
+		l.Info("a")
+		l.Info("b")
+		l.Info("c")
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l.Warn("d")
+		}()
+		if tc.policy == OverflowDropBelow {
+			// warnings block until there is room
+			waitFor(t, func() bool { return l.AsyncStats().Dropped == 1 })
+		} else {
+			wg.Wait()
+		}
+		close(hook.release)
+		wg.Wait()
+		if err := l.Shutdown(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		out := buf.String()
+		var got []string
+		for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+			got = append(got, line[strings.Index(line, "msg=")+4:strings.Index(line, " source=")])
+		}
+		if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+			t.Fatalf("policy %d: got %v, want %v", tc.policy, got, tc.want)
+		}
+		want := uint64(2)
+		if tc.policy == OverflowDropBelow {
+			want = 1
+		}
+		if st := l.AsyncStats(); st.Dropped != want {
+			t.Fatalf("policy %d: %d entries dropped instead of %d", tc.policy, st.Dropped, want)
+		}
+	}
+}
+
+func TestAsyncPanicBypassesQueue(t *testing.T) {
+	var buf syncBuffer
+	l := NewLogger(&buf)
+	l.SetAsync(&AsyncOptions{})
+	l.Info("queued")
+
+	func() {
+		defer func() { recover() }()
+		l.Panic("boom")
+	}()
+	// the queue is drained before the panic entry is written
+	out := buf.String()
+	if !strings.Contains(out, "msg=queued") || !strings.Contains(out, "msg=boom") || strings.Index(out, "queued") > strings.Index(out, "boom") {
+		t.Fatalf("unexpected output %q", out)
+	}
+}
+
+// waitFor polls cond until it is true, failing the test after 5 seconds.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met before the deadline")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// FuncKey is the field holding the name of the function that logged an
+// entry when SourceFunc is set.
+const FuncKey = "func"
+
+// SourceMode selects how the location of the logging call is reported in
+// the source field.
+type SourceMode uint32
+
+const (
+	// SourceShort reports the file name and line, e.g. main.go:12. It is
+	// the default.
+	SourceShort SourceMode = iota
+	// SourcePackage reports the file with the import path of its package,
+	// e.g. github.com/lwhile/app/server/main.go:12.
+	SourcePackage
+	// SourceFull reports the absolute path of the file as known by the
+	// compiler.
+	SourceFull
+	// SourceNone disables the caller lookup, entries have no source field.
+	SourceNone
+
+	// SourceFunc can be combined with the other modes to also report the
+	// name of the calling function in the func field, e.g. server.handle.
+	SourceFunc SourceMode = 1 << 4
+)
+
+const sourcePathMask = SourceFunc - 1
+
+// SetSourceMode sets how the standard logger reports the caller.
+func SetSourceMode(mode SourceMode) {
+	baseLogger.SetSourceMode(mode)
+}
+
+// WithCallerSkip returns a Logger that skips n more stack frames of the
+// standard logger when looking for the caller, see Logger.WithCallerSkip.
+func WithCallerSkip(n int) Logger {
+	return baseLogger.WithCallerSkip(n)
+}
+
+// SetSourceMode sets how the caller is reported by the logger and all the
+// loggers sharing its output.
+func (l logger) SetSourceMode(mode SourceMode) {
+	atomic.StoreUint32(&l.core.source, uint32(mode))
+}
+
+// WithCallerSkip returns a Logger that skips n more stack frames when
+// looking for the caller. Libraries wrapping this package use it so that
+// the source of an entry is the caller of the wrapper:
+//
+//	var logger = log.WithCallerSkip(1)
+//
+//	func Infof(format string, args ...interface{}) { logger.Infof(format, args...) }
+func (l logger) WithCallerSkip(n int) Logger {
+	l.skip += n
+	return l
+}
+
+func (c *core) sourceMode() SourceMode {
+	return SourceMode(atomic.LoadUint32(&c.source))
+}
+
+// caller returns the source and function name of the frame skip frames
+// above its caller, formatted according to mode.
+func caller(skip int, mode SourceMode) (source, function string) {
+	var pcs [1]uintptr
+	// skip runtime.Callers and caller itself
+	if runtime.Callers(skip+2, pcs[:]) == 0 {
+		return "<???>:1", ""
+	}
+	return pcSource(pcs[0], mode)
+}
+
+// pcSource returns the source and function name of the program counter
+// pc, as returned by runtime.Callers, formatted according to mode.
+func pcSource(pc uintptr, mode SourceMode) (source, function string) {
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	if frame.File == "" {
+		return "<???>:1", ""
+	}
+
+	file := frame.File
+	switch mode & sourcePathMask {
+	case SourceShort:
+		file = filepath.Base(file)
+	case SourcePackage:
+		if pkg := funcPackage(frame.Function); pkg != "" {
+			file = pkg + "/" + filepath.Base(file)
+		} else {
+			file = filepath.Base(file)
+		}
+	}
+	source = file + ":" + strconv.Itoa(frame.Line)
+
+	if mode&SourceFunc != 0 {
+		function = frame.Function
+		if slash := strings.LastIndex(function, "/"); slash >= 0 {
+			function = function[slash+1:]
+		}
+	}
+	return source, function
+}
+
+// funcPackage returns the import path of the package of the fully
+// qualified function name fn, e.g. github.com/a/b for
+// github.com/a/b.(*T).Method.
+func funcPackage(fn string) string {
+	slash := strings.LastIndex(fn, "/")
+	dot := strings.Index(fn[slash+1:], ".")
+	if dot < 0 {
+		return ""
+	}
+	return fn[:slash+1+dot]
+}
@@ -0,0 +1,86 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"bytes"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// nextLine returns the short source, file.go:12, of the line after its
+// call.
+func nextLine() string {
+	_, file, line, _ := runtime.Caller(1)
+	return filepath.Base(file) + ":" + strconv.Itoa(line+1)
+}
+
+func wrappedInfo(l Logger, msg string) (source string) {
+	source = nextLine()
+	l.Info(msg)
+	return source
+}
+
+func TestCallerSkip(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLogger(&buf)
+
+	source := wrappedInfo(l, "direct")
+	if !strings.Contains(buf.String(), `source="`+source+`"`) {
+		t.Fatalf("unexpected source in %q", buf.String())
+	}
+
+	buf.Reset()
+	source = nextLine()
+	wrappedInfo(l.WithCallerSkip(1).With("k", "v"), "skipped")
+	if !strings.Contains(buf.String(), `source="`+source+`"`) {
+		t.Fatalf("unexpected source in %q", buf.String())
+	}
+}
+
+func TestSourceMode(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLogger(&buf)
+
+	l.SetSourceMode(SourcePackage | SourceFunc)
+	source := nextLine()
+	l.Info("package")
+	out := buf.String()
+	if !strings.Contains(out, `source="`+logPackage+"/"+source+`"`) {
+		t.Fatalf("unexpected source in %q", out)
+	}
+	if !strings.Contains(out, "func=log.TestSourceMode") {
+		t.Fatalf("unexpected func in %q", out)
+	}
+
+	buf.Reset()
+	l.SetSourceMode(SourceFull)
+	source = nextLine()
+	l.Info("full")
+	if out := buf.String(); !strings.Contains(out, "/"+source+`"`) || strings.Contains(out, `source="caller_test.go`) || strings.Contains(out, "func=") {
+		t.Fatalf("unexpected source in %q", out)
+	}
+
+	buf.Reset()
+	l.SetSourceMode(SourceNone)
+	l.SetOutput(&buf)
+	l.(logger).entry.Logger.Formatter = PrefixedFormatter
+	l.Info("none")
+	if out := buf.String(); strings.Contains(out, "caller_test.go") || !strings.Contains(out, " [info] none") {
+		t.Fatalf("unexpected output %q", out)
+	}
+}
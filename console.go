@@ -0,0 +1,206 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// ColorMode selects when ConsoleFormatter writes colors.
+type ColorMode int
+
+const (
+	// ColorAuto writes colors when the output of the logger is a
+	// terminal. The NO_COLOR environment variable disables them and
+	// FORCE_COLOR, which takes precedence, enables them.
+	ColorAuto ColorMode = iota
+	// ColorAlways always writes colors.
+	ColorAlways
+	// ColorNever never writes colors.
+	ColorNever
+)
+
+// DefaultConsoleTimeLayout is the time layout of ConsoleFormatter when
+// TimeLayout is empty.
+const DefaultConsoleTimeLayout = "15:04:05.000"
+
+// DefaultConsoleSourceWidth is the width of the source column of
+// ConsoleFormatter when SourceWidth is zero.
+const DefaultConsoleSourceWidth = 24
+
+const (
+	colorReset   = "\x1b[0m"
+	colorDim     = "\x1b[2m"
+	colorRed     = "\x1b[31m"
+	colorGreen   = "\x1b[32m"
+	colorYellow  = "\x1b[33m"
+	colorMagenta = "\x1b[35m"
+	colorCyan    = "\x1b[36m"
+	colorGray    = "\x1b[90m"
+	colorBoldRed = "\x1b[1;31m"
+)
+
+var levelColors = map[logrus.Level]string{
+	logrus.Level(TraceLevel): colorGray,
+	logrus.DebugLevel:        colorGray,
+	logrus.InfoLevel:         colorGreen,
+	logrus.WarnLevel:         colorYellow,
+	logrus.ErrorLevel:        colorRed,
+	logrus.FatalLevel:        colorBoldRed,
+	logrus.PanicLevel:        colorBoldRed,
+}
+
+// ConsoleFormatter formats the entries for humans reading a terminal:
+//
+//	15:04:05.000 INFO  server.go:42             [api] started port=8080
+//
+// The time is dimmed, the level colored, the source aligned in a column
+// and the field keys colored. The stack trace is written indented below
+// the entry. It is selected with -log.format=logger:stderr?format=console.
+//
+// With ColorAuto the colors are decided for each output of the loggers
+// using the formatter, on their first entry. A hook formats the entries
+// of the logger it is added to, set Colors when the formatter is used by
+// a hook writing to a file.
+type ConsoleFormatter struct {
+	// Colors selects when colors are written.
+	Colors ColorMode
+	// TimeLayout is the layout of the time, DefaultConsoleTimeLayout if
+	// empty.
+	TimeLayout string
+	// SourceWidth is the width of the source column,
+	// DefaultConsoleSourceWidth if zero. A negative width disables the
+	// alignment.
+	SourceWidth int
+
+	// auto caches the result of ColorAuto by file descriptor
+	auto sync.Map
+}
+
+// Format implements logrus.Formatter.
+func (f *ConsoleFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	var out io.Writer
+	if entry.Logger != nil {
+		out = entry.Logger.Out
+	}
+	color := f.colored(out)
+	paint := func(b *bytes.Buffer, c, s string) {
+		if color {
+			b.WriteString(c + s + colorReset)
+		} else {
+			b.WriteString(s)
+		}
+	}
+
+	var b bytes.Buffer
+	layout := f.TimeLayout
+	if layout == "" {
+		layout = DefaultConsoleTimeLayout
+	}
+	paint(&b, colorDim, entry.Time.Format(layout))
+	b.WriteByte(' ')
+
+	level := strings.ToUpper(Level(entry.Level).String())
+	if len(level) < 5 {
+		level += strings.Repeat(" ", 5-len(level))
+	}
+	paint(&b, levelColors[entry.Level], level)
+	b.WriteByte(' ')
+
+	source := pString(entry.Data["source"])
+	if function := pString(entry.Data[FuncKey]); function != "" && source != "" {
+		source += " " + function
+	}
+	width := f.SourceWidth
+	if width == 0 {
+		width = DefaultConsoleSourceWidth
+	}
+	if source != "" {
+		if len(source) < width {
+			source += strings.Repeat(" ", width-len(source))
+		}
+		paint(&b, colorGray, source)
+		b.WriteByte(' ')
+	}
+
+	if name := pString(entry.Data[NameKey]); name != "" {
+		paint(&b, colorMagenta, "["+name+"]")
+		b.WriteByte(' ')
+	}
+	b.WriteString(entry.Message)
+
+	keys := make([]string, 0, len(entry.Data))
+	for k := range entry.Data {
+		if !pTokenKeys[k] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		b.WriteByte(' ')
+		paint(&b, colorCyan, k+"=")
+		appendPValue(&b, entry.Data[k])
+	}
+
+	if st, ok := entry.Data[StacktraceKey].(Stacktrace); ok {
+		var s bytes.Buffer
+		st.appendText(&s)
+		for _, line := range strings.Split(s.String(), "\n") {
+			b.WriteString("\n    ")
+			paint(&b, colorGray, line)
+		}
+	}
+	b.WriteByte('\n')
+	return b.Bytes(), nil
+}
+
+// colored reports whether the entries written to out are colored.
+func (f *ConsoleFormatter) colored(out io.Writer) bool {
+	switch f.Colors {
+	case ColorAlways:
+		return true
+	case ColorNever:
+		return false
+	}
+	file, ok := out.(interface{ Fd() uintptr })
+	if !ok {
+		return autoColors(out)
+	}
+	if auto, ok := f.auto.Load(file.Fd()); ok {
+		return auto.(bool)
+	}
+	auto := autoColors(out)
+	f.auto.Store(file.Fd(), auto)
+	return auto
+}
+
+// autoColors implements ColorAuto.
+func autoColors(out io.Writer) bool {
+	if force := os.Getenv("FORCE_COLOR"); force != "" && force != "0" && force != "false" {
+		return true
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	f, ok := out.(interface{ Fd() uintptr })
+	return ok && terminal.IsTerminal(int(f.Fd()))
+}
@@ -0,0 +1,125 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestConsoleFormatter(t *testing.T) {
+	entry := &logrus.Entry{
+		Data:    logrus.Fields{"source": "server.go:42", NameKey: "api", "port": Int("port", 8080), "addr": "a b"},
+		Time:    time.Date(2024, 3, 1, 8, 30, 0, 123456789, time.UTC),
+		Level:   logrus.InfoLevel,
+		Message: "started",
+	}
+	b, err := (&ConsoleFormatter{Colors: ColorNever}).Format(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(b); got != "08:30:00.123 INFO  server.go:42             [api] started addr=\"a b\" port=8080\n" {
+		t.Fatalf("unexpected output %q", got)
+	}
+
+	b, _ = (&ConsoleFormatter{Colors: ColorAlways, SourceWidth: -1}).Format(entry)
+	if got := string(b); !strings.HasPrefix(got, colorDim+"08:30:00.123"+colorReset+" "+colorGreen+"INFO "+colorReset+" "+colorGray+"server.go:42"+colorReset+" ") ||
+		!strings.Contains(got, colorCyan+"port="+colorReset+"8080") {
+		t.Fatalf("unexpected output %q", got)
+	}
+
+	delete(entry.Data, "source")
+	b, _ = (&ConsoleFormatter{Colors: ColorNever}).Format(entry)
+	if got := string(b); got != "08:30:00.123 INFO  [api] started addr=\"a b\" port=8080\n" {
+		t.Fatalf("unexpected output without source %q", got)
+	}
+}
+
+func TestConsoleFormatterStacktrace(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLogger(&buf)
+	l.(logger).entry.Logger.Formatter = &ConsoleFormatter{}
+
+	var source string
+	func() {
+		defer func() { recover() }()
+		source = nextLine()
+		l.Panic("boom")
+	}()
+	lines := strings.Split(buf.String(), "\n")
+	if len(lines) < 4 || !strings.Contains(lines[0], "PANIC "+source) || !strings.HasSuffix(lines[0], " boom") {
+		t.Fatalf("unexpected output %q", buf.String())
+	}
+	if !strings.HasPrefix(lines[1], "    github.com/lwhile/log.TestConsoleFormatterStacktrace") || !strings.HasPrefix(lines[2], "    \t") {
+		t.Fatalf("stack trace not indented in %q", buf.String())
+	}
+}
+
+func TestConsoleAutoColors(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("FORCE_COLOR", "")
+	if autoColors(&bytes.Buffer{}) {
+		t.Fatal("colors for a buffer")
+	}
+	t.Setenv("FORCE_COLOR", "1")
+	t.Setenv("NO_COLOR", "1")
+	if !autoColors(&bytes.Buffer{}) {
+		t.Fatal("FORCE_COLOR ignored")
+	}
+	t.Setenv("FORCE_COLOR", "0")
+	if autoColors(&bytes.Buffer{}) {
+		t.Fatal("NO_COLOR ignored")
+	}
+
+	t.Setenv("NO_COLOR", "")
+	f := &ConsoleFormatter{}
+	file, err := os.Create(filepath.Join(t.TempDir(), "app.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if f.colored(file) {
+		t.Fatal("colors for a file")
+	}
+	t.Setenv("FORCE_COLOR", "1")
+	if !f.colored(&bytes.Buffer{}) {
+		t.Fatal("colors decided by the first output")
+	}
+}
+
+func TestLogFormatFlag(t *testing.T) {
+	formatter, out := origLogger.Formatter, origLogger.Out
+	defer func() {
+		origLogger.Formatter, origLogger.Out = formatter, out
+	}()
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	AddFlags(fs)
+	if err := fs.Parse([]string{"-log.format=logger:stderr?format=console"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := origLogger.Formatter.(*ConsoleFormatter); !ok {
+		t.Fatalf("unexpected formatter %T", origLogger.Formatter)
+	}
+	if err := fs.Set("log.format", "logger:stderr?format=xml"); err == nil {
+		t.Fatal("unknown format accepted")
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"context"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+type loggerKey struct{}
+
+// ContextExtractor returns the fields that should be attached to
+// every entry logged with the given context.
+type ContextExtractor func(ctx context.Context) map[string]interface{}
+
+var (
+	extractorsMu sync.RWMutex
+	extractors   []ContextExtractor
+)
+
+// RegisterContextExtractor registers fn so that the fields it returns are
+// added to every entry logged by a Logger bound to a context.
+func RegisterContextExtractor(fn ContextExtractor) {
+	extractorsMu.Lock()
+	defer extractorsMu.Unlock()
+	extractors = append(extractors, fn)
+}
+
+// RegisterContextKey registers an extractor that adds the value stored
+// in a context under key as the field named field.
+func RegisterContextKey(key interface{}, field string) {
+	RegisterContextExtractor(func(ctx context.Context) map[string]interface{} {
+		v := ctx.Value(key)
+		if v == nil {
+			return nil
+		}
+		return map[string]interface{}{field: v}
+	})
+}
+
+func contextFields(ctx context.Context) logrus.Fields {
+	extractorsMu.RLock()
+	defer extractorsMu.RUnlock()
+
+	var fields logrus.Fields
+	for _, fn := range extractors {
+		for k, v := range fn(ctx) {
+			if fields == nil {
+				fields = make(logrus.Fields)
+			}
+			fields[k] = v
+		}
+	}
+	return fields
+}
+
+// NewContext returns a copy of ctx that carries l.
+func NewContext(ctx context.Context, l Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// FromContext returns the Logger stored in ctx by NewContext, or the base
+// logger if there is none. The returned Logger is bound to ctx.
+func FromContext(ctx context.Context) Logger {
+	if l, ok := ctx.Value(loggerKey{}).(Logger); ok {
+		return l.WithContext(ctx)
+	}
+	return baseLogger.WithContext(ctx)
+}
+
+// WithContext returns a Logger bound to ctx. Fields returned by the
+// registered context extractors are added on every call.
+func WithContext(ctx context.Context) Logger {
+	return baseLogger.WithContext(ctx)
+}
+
+func (l logger) WithContext(ctx context.Context) Logger {
+	l.ctx = ctx
+	return l
+}
@@ -0,0 +1,67 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+type testCtxKey string
+
+// restoreExtractors unregisters the extractors registered by the test
+// when it ends.
+func restoreExtractors(t *testing.T) {
+	extractorsMu.RLock()
+	saved := extractors
+	extractorsMu.RUnlock()
+	t.Cleanup(func() {
+		extractorsMu.Lock()
+		extractors = saved
+		extractorsMu.Unlock()
+	})
+}
+
+func TestContextLogger(t *testing.T) {
+	restoreExtractors(t)
+	RegisterContextKey(testCtxKey("request_id"), "request_id")
+
+	var buf bytes.Buffer
+	l := NewLogger(&buf)
+
+	ctx := context.WithValue(context.Background(), testCtxKey("request_id"), "abc123")
+	ctx = NewContext(ctx, l.With("component", "test"))
+
+	FromContext(ctx).Info("hello")
+
+	out := buf.String()
+	if !strings.Contains(out, "request_id=abc123") {
+		t.Fatalf("request_id not found in %q", out)
+	}
+	if !strings.Contains(out, "component=test") {
+		t.Fatalf("component not found in %q", out)
+	}
+	if !strings.Contains(out, "source=\"context_test.go:") {
+		t.Fatalf("source not found in %q", out)
+	}
+}
+
+func TestFromContextWithoutLogger(t *testing.T) {
+	l := FromContext(context.Background())
+	if l == nil {
+		t.Fatal("FromContext returned nil")
+	}
+}
@@ -0,0 +1,194 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// RepeatedKey, FirstKey and LastKey are the fields of the summary entries
+// logged for the duplicates collapsed by SetDedup.
+const (
+	RepeatedKey = "repeated"
+	FirstKey    = "first"
+	LastKey     = "last"
+)
+
+// dedupIgnoredKeys are the fields that differ between entries logged by
+// the same statement and are not compared.
+var dedupIgnoredKeys = map[string]bool{"source": true, FuncKey: true, StacktraceKey: true}
+
+// SetDedup collapses the consecutive duplicates logged by the standard
+// logger, see Logger.SetDedup.
+func SetDedup(window time.Duration) {
+	baseLogger.SetDedup(window)
+}
+
+// SetDedup collapses consecutive entries with the same level, message and
+// fields, their time, source and stack trace aside, into the first one
+// followed by a summary entry "last message repeated N times" carrying
+// the time of the first and last duplicate. The summary is logged when
+// another entry is logged, when window has passed since the first entry,
+// or on shutdown. A window of zero disables deduplication.
+//
+// Deduplication applies to the output and every hook. Panic and Fatal
+// entries are never collapsed.
+func (l logger) SetDedup(window time.Duration) {
+	var d *deduper
+	if window > 0 {
+		d = &deduper{window: window}
+	}
+	old, _ := l.core.dedup.Load().(*deduper)
+	l.core.dedup.Store(d)
+	if old != nil {
+		old.flush(l.core)
+	}
+}
+
+type deduper struct {
+	window time.Duration
+
+	mu sync.Mutex
+	// prev is the last entry that was written, first and last the times
+	// of the first and last duplicates of it
+	prev        *logrus.Entry
+	repeated    int
+	first, last time.Time
+	timer       *time.Timer
+}
+
+// admit reports whether the entry should be written, collapsing it if it
+// repeats the previous one. The summary of the previous entry is written
+// first when needed.
+func (d *deduper) admit(c *core, entry *logrus.Entry) bool {
+	now := entry.Time
+
+	d.mu.Lock()
+	if entry.Level > logrus.FatalLevel && d.prev != nil && now.Sub(d.prev.Time) < d.window && d.repeats(entry) {
+		if d.repeated == 0 {
+			d.first = now
+			prev := d.prev
+			d.timer = time.AfterFunc(d.window-now.Sub(prev.Time), func() { d.expire(c, prev) })
+		}
+		d.repeated++
+		d.last = now
+		d.mu.Unlock()
+		return false
+	}
+
+	summary := d.summary()
+	d.prev = &logrus.Entry{Data: entry.Data, Time: now, Level: entry.Level, Message: entry.Message}
+	d.mu.Unlock()
+
+	if summary != nil {
+		c.writeSummary(summary)
+	}
+	return true
+}
+
+// repeats reports whether the entry repeats the previous one.
+func (d *deduper) repeats(entry *logrus.Entry) bool {
+	if entry.Level != d.prev.Level || entry.Message != d.prev.Message {
+		return false
+	}
+	n := 0
+	for k, v := range entry.Data {
+		if dedupIgnoredKeys[k] {
+			continue
+		}
+		pv, ok := d.prev.Data[k]
+		if !ok || !reflect.DeepEqual(v, pv) {
+			return false
+		}
+		n++
+	}
+	for k := range d.prev.Data {
+		if !dedupIgnoredKeys[k] {
+			n--
+		}
+	}
+	return n == 0
+}
+
+// summary returns the summary entry of the duplicates of the previous
+// entry, if any, and resets their count. d.mu must be held.
+func (d *deduper) summary() *logrus.Entry {
+	if d.repeated == 0 {
+		return nil
+	}
+	d.timer.Stop()
+	data := make(logrus.Fields, len(d.prev.Data)+3)
+	for k, v := range d.prev.Data {
+		if k != StacktraceKey {
+			data[k] = v
+		}
+	}
+	data[RepeatedKey] = d.repeated
+	data[FirstKey] = d.first.Format(time.RFC3339Nano)
+	data[LastKey] = d.last.Format(time.RFC3339Nano)
+	summary := &logrus.Entry{Data: data, Level: d.prev.Level, Message: fmt.Sprintf("last message repeated %d times", d.repeated)}
+	d.repeated = 0
+	return summary
+}
+
+// expire writes the summary of the duplicates of prev once the window
+// has passed, later duplicates are written again.
+func (d *deduper) expire(c *core, prev *logrus.Entry) {
+	d.mu.Lock()
+	if d.prev != prev {
+		d.mu.Unlock()
+		return
+	}
+	summary := d.summary()
+	d.prev = nil
+	d.mu.Unlock()
+
+	if summary != nil {
+		c.writeSummary(summary)
+	}
+}
+
+// flush writes the pending summary and forgets the previous entry.
+func (d *deduper) flush(c *core) {
+	d.mu.Lock()
+	summary := d.summary()
+	d.prev = nil
+	d.mu.Unlock()
+
+	if summary != nil {
+		c.writeSummary(summary)
+	}
+}
+
+// writeSummary writes a summary entry, bypassing deduplication.
+func (c *core) writeSummary(summary *logrus.Entry) {
+	summary.Logger = c.logger
+	summary.Time = time.Now()
+	if a, _ := c.async.Load().(*dispatcher); a != nil && a.enqueue(summary) {
+		return
+	}
+	c.output(summary)
+}
+
+// flushDedup writes the pending summary, it is called on shutdown.
+func (c *core) flushDedup() {
+	if d, _ := c.dedup.Load().(*deduper); d != nil {
+		d.flush(c)
+	}
+}
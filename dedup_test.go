@@ -0,0 +1,104 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestDedup(t *testing.T) {
+	var buf syncBuffer
+	l := NewLogger(&buf)
+	hook := &countHook{}
+	if _, err := l.AddHook(hook, InfoLevel); err != nil {
+		t.Fatal(err)
+	}
+	l.SetDedup(time.Hour)
+
+	err := errors.New("connection refused")
+	for i := 0; i < 5; i++ {
+		// the source differs but is not compared
+		l.WithError(err).Error("retrying")
+		l.WithError(err).
+			Error("retrying")
+	}
+	l.WithError(errors.New("timeout")).Error("retrying")
+	l.Info("done")
+
+	out := buf.String()
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("unexpected output %q", out)
+	}
+	if !strings.Contains(lines[0], `error="connection refused"`) || !strings.Contains(lines[1], `msg="last message repeated 9 times"`) ||
+		!strings.Contains(lines[1], "repeated=9") || !strings.Contains(lines[1], "first=") || !strings.Contains(lines[1], "last=") ||
+		!strings.Contains(lines[2], "error=timeout") || !strings.Contains(lines[3], "msg=done") {
+		t.Fatalf("unexpected output %q", out)
+	}
+	if hook.count() != 4 {
+		t.Fatalf("hook fired %d times instead of 4", hook.count())
+	}
+
+	l.Info("done")
+	l.Info("done")
+	if err := l.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `msg="last message repeated 2 times"`) {
+		t.Fatalf("summary not written on shutdown in %q", buf.String())
+	}
+}
+
+func TestDedupWindow(t *testing.T) {
+	var buf syncBuffer
+	l := NewLogger(&buf)
+	l.SetDedup(20 * time.Millisecond)
+
+	l.Warn("disk full")
+	l.Warn("disk full")
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(buf.String(), "repeated 1 times") {
+		if time.Now().After(deadline) {
+			t.Fatalf("summary not written after the window in %q", buf.String())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	l.Warn("disk full")
+	if n := strings.Count(buf.String(), `msg="disk full"`); n != 2 {
+		t.Fatalf("%d entries written instead of 2 in %q", n, buf.String())
+	}
+}
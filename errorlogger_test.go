@@ -0,0 +1,43 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/lwhile/log"
+)
+
+// The entries of the error logger take the source of the first frame
+// outside the log packages, so it is tested from outside this package.
+func TestErrorLoggerSource(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+	el := log.NewErrorLogger()
+
+	_, file, line, _ := runtime.Caller(0)
+	el.Printf("http: %s", "boom")
+	source := filepath.Base(file) + ":" + strconv.Itoa(line+1)
+	out := buf.String()
+	if !strings.Contains(out, source) || !strings.Contains(out, "http: boom") {
+		t.Fatalf("source %s not found in %q", source, out)
+	}
+}
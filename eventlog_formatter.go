@@ -73,7 +73,7 @@ func (s *eventlogger) Format(e *logrus.Entry) ([]byte, error) {
 		err = s.log.Warning(101, e.Message)
 	case logrus.InfoLevel:
 		err = s.log.Info(100, e.Message)
-	case logrus.DebugLevel:
+	case logrus.DebugLevel, logrus.Level(TraceLevel):
 		if s.debugAsInfo {
 			err = s.log.Info(100, e.Message)
 		}
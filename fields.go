@@ -0,0 +1,280 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/sirupsen/logrus"
+)
+
+type fieldType uint8
+
+const (
+	anyType fieldType = iota
+	stringType
+	int64Type
+	float64Type
+	boolType
+	durationType
+	errorType
+	stringerType
+)
+
+// Field is a typed key/value pair passed to Log. Constructing a Field does
+// not allocate; the field is only encoded when the entry is written.
+//
+// Fields are stored in the entry as Field values which encode themselves
+// (they implement json.Marshaler and fmt.Stringer), except Err and Any
+// which keep the original value so hooks like Sentry still receive it.
+type Field struct {
+	Key     string
+	typ     fieldType
+	integer int64
+	str     string
+	iface   interface{}
+}
+
+// String constructs a field with a string value.
+func String(key, val string) Field {
+	return Field{Key: key, typ: stringType, str: val}
+}
+
+// Int constructs a field with an int value.
+func Int(key string, val int) Field {
+	return Int64(key, int64(val))
+}
+
+// Int64 constructs a field with an int64 value.
+func Int64(key string, val int64) Field {
+	return Field{Key: key, typ: int64Type, integer: val}
+}
+
+// Float64 constructs a field with a float64 value.
+func Float64(key string, val float64) Field {
+	return Field{Key: key, typ: float64Type, integer: int64(math.Float64bits(val))}
+}
+
+// Bool constructs a field with a bool value.
+func Bool(key string, val bool) Field {
+	var i int64
+	if val {
+		i = 1
+	}
+	return Field{Key: key, typ: boolType, integer: i}
+}
+
+// Duration constructs a field with a time.Duration value.
+func Duration(key string, val time.Duration) Field {
+	return Field{Key: key, typ: durationType, integer: int64(val)}
+}
+
+// Err constructs a field with the given error under logrus.ErrorKey.
+func Err(err error) Field {
+	return Field{Key: logrus.ErrorKey, typ: errorType, iface: err}
+}
+
+// Stringer constructs a field whose value is the result of val.String(),
+// evaluated only when the entry is written.
+func Stringer(key string, val fmt.Stringer) Field {
+	return Field{Key: key, typ: stringerType, iface: val}
+}
+
+// Any constructs a field with an arbitrary value.
+func Any(key string, val interface{}) Field {
+	return Field{Key: key, typ: anyType, iface: val}
+}
+
+// Value returns the field value as a plain Go value.
+func (f Field) Value() interface{} {
+	switch f.typ {
+	case stringType:
+		return f.str
+	case int64Type:
+		return f.integer
+	case float64Type:
+		return math.Float64frombits(uint64(f.integer))
+	case boolType:
+		return f.integer == 1
+	case durationType:
+		return time.Duration(f.integer)
+	}
+	return f.iface
+}
+
+// dataValue returns what is stored in entry.Data for the field.
+func (f Field) dataValue() interface{} {
+	if f.typ == anyType || f.typ == errorType {
+		return f.iface
+	}
+	return f
+}
+
+// String implements fmt.Stringer.
+func (f Field) String() string {
+	switch f.typ {
+	case stringType:
+		return f.str
+	case int64Type:
+		return strconv.FormatInt(f.integer, 10)
+	case durationType:
+		return time.Duration(f.integer).String()
+	}
+	var b bytes.Buffer
+	f.appendText(&b)
+	return b.String()
+}
+
+// MarshalJSON implements json.Marshaler.
+func (f Field) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	f.appendJSON(&b)
+	return b.Bytes(), nil
+}
+
+func (f Field) appendText(b *bytes.Buffer) {
+	switch f.typ {
+	case stringType:
+		b.WriteString(f.str)
+	case int64Type:
+		var tmp [32]byte
+		b.Write(strconv.AppendInt(tmp[:0], f.integer, 10))
+	case float64Type:
+		var tmp [32]byte
+		b.Write(strconv.AppendFloat(tmp[:0], math.Float64frombits(uint64(f.integer)), 'g', -1, 64))
+	case boolType:
+		b.WriteString(strconv.FormatBool(f.integer == 1))
+	case durationType:
+		b.WriteString(time.Duration(f.integer).String())
+	case stringerType:
+		s, _ := f.iface.(fmt.Stringer)
+		b.WriteString(stringerText(s))
+	default:
+		fmt.Fprint(b, f.iface)
+	}
+}
+
+// stringerText returns s.String(), "<nil>" for a nil s and the panic of
+// String formatted like fmt does.
+func stringerText(s fmt.Stringer) (text string) {
+	if s == nil {
+		return "<nil>"
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			if v := reflect.ValueOf(s); v.Kind() == reflect.Ptr && v.IsNil() {
+				text = "<nil>"
+				return
+			}
+			text = fmt.Sprintf("%%!v(PANIC=String method: %v)", p)
+		}
+	}()
+	return s.String()
+}
+
+func (f Field) appendJSON(b *bytes.Buffer) {
+	switch f.typ {
+	case int64Type:
+		var tmp [32]byte
+		b.Write(strconv.AppendInt(tmp[:0], f.integer, 10))
+	case float64Type:
+		v := math.Float64frombits(uint64(f.integer))
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			// not representable in JSON
+			appendJSONString(b, strconv.FormatFloat(v, 'g', -1, 64))
+			return
+		}
+		var tmp [32]byte
+		b.Write(strconv.AppendFloat(tmp[:0], v, 'g', -1, 64))
+	case boolType:
+		b.WriteString(strconv.FormatBool(f.integer == 1))
+	case stringType:
+		appendJSONString(b, f.str)
+	default:
+		var s bytes.Buffer
+		f.appendText(&s)
+		appendJSONString(b, s.String())
+	}
+}
+
+const hexDigits = "0123456789abcdef"
+
+// appendJSONString writes s as a quoted JSON string.
+func appendJSONString(b *bytes.Buffer, s string) {
+	b.WriteByte('"')
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			switch {
+			case c == '"' || c == '\\':
+				b.WriteByte('\\')
+				b.WriteByte(c)
+			case c == '\n':
+				b.WriteString(`\n`)
+			case c == '\r':
+				b.WriteString(`\r`)
+			case c == '\t':
+				b.WriteString(`\t`)
+			case c < 0x20:
+				b.WriteString(`\u00`)
+				b.WriteByte(hexDigits[c>>4])
+				b.WriteByte(hexDigits[c&0xF])
+			default:
+				b.WriteByte(c)
+			}
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			b.WriteString(`\ufffd`)
+		} else {
+			b.WriteString(s[i : i+size])
+		}
+		i += size
+	}
+	b.WriteByte('"')
+}
+
+// Log logs msg at level with the given typed fields. Nothing is allocated
+// when level is disabled. Like Fatal and Panic, it exits at FatalLevel and
+// panics at PanicLevel, even when the level is disabled.
+func (l logger) Log(level Level, msg string, fields ...Field) {
+	if l.IsEnabled(level) {
+		l.log(level, msg, fields)
+	} else if level == FatalLevel {
+		l.core.fatalExit()
+	}
+	if level == PanicLevel {
+		panic(msg)
+	}
+}
+
+// Log logs msg at level with the given typed fields on the standard logger.
+func Log(level Level, msg string, fields ...Field) {
+	if baseLogger.IsEnabled(level) {
+		baseLogger.log(level, msg, fields)
+	} else if level == FatalLevel {
+		baseLogger.core.fatalExit()
+	}
+	if level == PanicLevel {
+		panic(msg)
+	}
+}
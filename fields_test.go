@@ -0,0 +1,170 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestFieldJSON(t *testing.T) {
+	testCases := []struct {
+		field Field
+		want  string
+	}{
+		{String("k", "a \"quoted\"\nline"), `"a \"quoted\"\nline"`},
+		{Int64("k", -42), `-42`},
+		{Int("k", 7), `7`},
+		{Float64("k", 1.5), `1.5`},
+		{Bool("k", true), `true`},
+		{Duration("k", 1500*time.Millisecond), `"1.5s"`},
+		{Stringer("k", InfoLevel), `"info"`},
+	}
+	for _, tc := range testCases {
+		got, err := json.Marshal(tc.field)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tc.want {
+			t.Errorf("want %s, got %s", tc.want, got)
+		}
+		if !json.Valid(got) {
+			t.Errorf("invalid JSON %s", got)
+		}
+	}
+}
+
+func TestLogFields(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLogger(&buf)
+	l.(logger).entry.Logger.Formatter = &logrus.JSONFormatter{}
+
+	err := errors.New("boom")
+	l.Log(InfoLevel, "request done", String("path", "/api"), Int("status", 200), Duration("took", time.Second), Err(err))
+
+	var data map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &data); err != nil {
+		t.Fatalf("%v: %s", err, buf.String())
+	}
+	if data["path"] != "/api" || data["status"] != float64(200) || data["took"] != "1s" || data["error"] != "boom" {
+		t.Fatalf("unexpected data %v", data)
+	}
+	if !strings.HasPrefix(data["source"].(string), "fields_test.go:") {
+		t.Fatalf("unexpected source %v", data["source"])
+	}
+}
+
+func TestLogFieldsPFormatter(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLogger(&buf)
+	l.(logger).entry.Logger.Formatter = PrefixedFormatter
+
+	l.Log(WarnLevel, "slow", String("path", "/api"), Int("status", 200))
+	if !strings.HasSuffix(buf.String(), "slow path=/api status=200\n") {
+		t.Fatalf("unexpected output %q", buf.String())
+	}
+}
+
+func TestLogDisabled(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLogger(&buf)
+	l.(logger).entry.Logger.Level = logrus.InfoLevel
+
+	l.Log(DebugLevel, "hidden", String("k", "v"))
+	if buf.Len() != 0 {
+		t.Fatalf("unexpected output %q", buf.String())
+	}
+}
+
+func newBenchLogger(level logrus.Level) Logger {
+	l := NewLogger(ioutil.Discard)
+	l.(logger).entry.Logger.Level = level
+	return l
+}
+
+func BenchmarkWithChain(b *testing.B) {
+	l := newBenchLogger(logrus.DebugLevel)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.With("path", "/api").With("status", 200).With("took", time.Millisecond).Info("request done")
+	}
+}
+
+func BenchmarkLogFields(b *testing.B) {
+	l := newBenchLogger(logrus.DebugLevel)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.Log(InfoLevel, "request done", String("path", "/api"), Int("status", 200), Duration("took", time.Millisecond))
+	}
+}
+
+func BenchmarkWithChainDisabled(b *testing.B) {
+	l := newBenchLogger(logrus.InfoLevel)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.With("path", "/api").With("status", 200).With("took", time.Millisecond).Debug("request done")
+	}
+}
+
+func BenchmarkLogFieldsDisabled(b *testing.B) {
+	l := newBenchLogger(logrus.InfoLevel)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.Log(DebugLevel, "request done", String("path", "/api"), Int("status", 200), Duration("took", time.Millisecond))
+	}
+}
+
+// panicStringer panics in String unless it is nil.
+type panicStringer struct{ msg string }
+
+func (s *panicStringer) String() string {
+	if s.msg == "" {
+		return "empty"
+	}
+	panic(s.msg)
+}
+
+func TestStringerFieldNil(t *testing.T) {
+	fields := []Field{
+		Stringer("nil", nil),
+		Stringer("typed", (*panicStringer)(nil)),
+		Stringer("panics", &panicStringer{msg: "boom"}),
+	}
+	for _, tc := range []struct {
+		formatter logrus.Formatter
+		want      []string
+	}{
+		{&logrus.JSONFormatter{}, []string{`"nil":"\u003cnil\u003e"`, `"typed":"\u003cnil\u003e"`, `"panics":"%!v(PANIC=String method: boom)"`}},
+		{&PFormatter{}, []string{`nil=<nil>`, `typed=<nil>`, `panics="%!v(PANIC=String method: boom)"`}},
+		{&LogfmtFormatter{}, []string{`nil=<nil>`, `typed=<nil>`, `panics="%!v(PANIC=String method: boom)"`}},
+		{&ConsoleFormatter{Colors: ColorNever}, []string{`nil=<nil>`, `typed=<nil>`, `panics="%!v(PANIC=String method: boom)"`}},
+	} {
+		var buf bytes.Buffer
+		l := NewLogger(&buf)
+		l.(logger).entry.Logger.Formatter = tc.formatter
+		l.Log(InfoLevel, "stringers", fields...)
+		for _, want := range tc.want {
+			if !strings.Contains(buf.String(), want) {
+				t.Errorf("%T: %s not found in %q", tc.formatter, want, buf.String())
+			}
+		}
+	}
+}
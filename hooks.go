@@ -0,0 +1,400 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/evalphobia/logrus_sentry"
+	"github.com/lestrrat/go-file-rotatelogs"
+	"github.com/lwhile/logrus-graylog-hook"
+	"github.com/sirupsen/logrus"
+)
+
+// HookInfo describes a hook registered with one of the Add*Hook functions.
+// AddHook and the …WithInfo variants of the other Add*Hook functions return
+// it, Hooks lists all of them in the order they were added. Its ID is passed
+// to RemoveHook, ReplaceHook and SetHookSampled.
+type HookInfo struct {
+	ID     int    `json:"id"`
+	Kind   string `json:"kind"`
+	Target string `json:"target,omitempty"`
+	Level  Level  `json:"level"`
+
+	// Unsampled hooks receive the entries dropped by sampling
+	Unsampled bool `json:"unsampled,omitempty"`
+}
+
+type hookEntry struct {
+	HookInfo
+	hook logrus.Hook
+
+	// writer is the file written by rotate hooks
+	writer *rotatelogs.RotateLogs
+}
+
+// AddHook adds hook to the standard logger, it is fired for entries at
+// level or above.
+func AddHook(hook logrus.Hook, level Level) (HookInfo, error) {
+	return baseLogger.AddHook(hook, level)
+}
+
+// Hooks returns the hooks registered on the standard logger.
+func Hooks() []HookInfo {
+	return baseLogger.Hooks()
+}
+
+// RemoveHook removes the hook with the given id from the standard logger
+// and flushes it.
+func RemoveHook(id int) error {
+	return baseLogger.RemoveHook(id)
+}
+
+// ReplaceHook replaces the hook with the given id of the standard logger
+// by hook, keeping its id and level. The old hook is flushed.
+func ReplaceHook(id int, hook logrus.Hook) error {
+	return baseLogger.ReplaceHook(id, hook)
+}
+
+// AddRotateHookWithInfo is like AddRotateHook and returns the HookInfo of the hook.
+func AddRotateHookWithInfo(path string, maxAge, rotateTime time.Duration, format string, level Level) (HookInfo, error) {
+	return addRotateHook(baseLogger, path, maxAge, rotateTime, format, dftFormatter, level)
+}
+
+// AddRotateHookWithFormatterWithInfo is like AddRotateHookWithFormatter and returns the HookInfo of the hook.
+func AddRotateHookWithFormatterWithInfo(path string, maxAge, rotateTime time.Duration, format string, formatter Formatter, level Level) (HookInfo, error) {
+	return addRotateHook(baseLogger, path, maxAge, rotateTime, format, formatter, level)
+}
+
+// AddRotateHookByDayWithInfo is like AddRotateHookByDay and returns the HookInfo of the hook.
+func AddRotateHookByDayWithInfo(path string, maxAge, rotateDay int, level Level) (HookInfo, error) {
+	return addRotateHookByDay(baseLogger, path, maxAge, rotateDay, dftFormatter, level)
+}
+
+// AddRotateHookByDayWithFormatterWithInfo is like AddRotateHookByDayWithFormatter and returns the HookInfo of the hook.
+func AddRotateHookByDayWithFormatterWithInfo(path string, maxAge, rotateDay int, formatter Formatter, level Level) (HookInfo, error) {
+	return addRotateHookByDay(baseLogger, path, maxAge, rotateDay, formatter, level)
+}
+
+// AddRotateHookByHourWithInfo is like AddRotateHookByHour and returns the HookInfo of the hook.
+func AddRotateHookByHourWithInfo(path string, maxAge, rotateHour int, level Level) (HookInfo, error) {
+	return addRotateHookByHour(baseLogger, path, maxAge, rotateHour, dftFormatter, level)
+}
+
+// AddRotateHookByHourWithFormatterWithInfo is like AddRotateHookByHourWithFormatter and returns the HookInfo of the hook.
+func AddRotateHookByHourWithFormatterWithInfo(path string, maxAge, rotateHour int, formatter Formatter, level Level) (HookInfo, error) {
+	return addRotateHookByHour(baseLogger, path, maxAge, rotateHour, formatter, level)
+}
+
+// AddSentryHookWithInfo is like AddSentryHook and returns the HookInfo of the hook.
+func AddSentryHookWithInfo(dsn string, level Level) (HookInfo, error) {
+	return addSentryHook(baseLogger, dsn, level)
+}
+
+// AddSentryHookWithTagWithInfo is like AddSentryHookWithTag and returns the HookInfo of the hook.
+func AddSentryHookWithTagWithInfo(dsn string, tags map[string]string, level Level) (HookInfo, error) {
+	return addSentryHookWithTag(baseLogger, dsn, tags, level)
+}
+
+// AddAsyncSentryHookWithInfo is like AddAsyncSentryHook and returns the HookInfo of the hook.
+func AddAsyncSentryHookWithInfo(dsn string, level Level) (HookInfo, error) {
+	return addAsyncSentryHook(baseLogger, dsn, level)
+}
+
+// AddGrayLogHookWithInfo is like AddGrayLogHook and returns the HookInfo of the hook.
+func AddGrayLogHookWithInfo(ip string, port int, extra map[string]interface{}, level Level) (HookInfo, error) {
+	return addGrayLogHook(baseLogger, ip, port, extra, level)
+}
+
+// AddAsyncGraylogHookWithInfo is like AddAsyncGraylogHook and returns the HookInfo of the hook.
+func AddAsyncGraylogHookWithInfo(ip string, port int, extra map[string]interface{}, level Level) (HookInfo, error) {
+	return addAsyncGraylogHook(baseLogger, ip, port, extra, level)
+}
+
+func (l logger) AddRotateHookWithInfo(path string, maxAge, rotateTime time.Duration, format string, level Level) (HookInfo, error) {
+	return addRotateHook(l, path, maxAge, rotateTime, format, dftFormatter, level)
+}
+
+func (l logger) AddRotateHookWithFormatterWithInfo(path string, maxAge, rotateTime time.Duration, format string, formatter Formatter, level Level) (HookInfo, error) {
+	return addRotateHook(l, path, maxAge, rotateTime, format, formatter, level)
+}
+
+func (l logger) AddRotateHookByDayWithInfo(path string, maxAge, rotateDay int, level Level) (HookInfo, error) {
+	return addRotateHookByDay(l, path, maxAge, rotateDay, dftFormatter, level)
+}
+
+func (l logger) AddRotateHookByDayWithFormatterWithInfo(path string, maxAge, rotateDay int, formatter Formatter, level Level) (HookInfo, error) {
+	return addRotateHookByDay(l, path, maxAge, rotateDay, formatter, level)
+}
+
+func (l logger) AddRotateHookByHourWithInfo(path string, maxAge, rotateHour int, level Level) (HookInfo, error) {
+	return addRotateHookByHour(l, path, maxAge, rotateHour, dftFormatter, level)
+}
+
+func (l logger) AddRotateHookByHourWithFormatterWithInfo(path string, maxAge, rotateHour int, formatter Formatter, level Level) (HookInfo, error) {
+	return addRotateHookByHour(l, path, maxAge, rotateHour, formatter, level)
+}
+
+func (l logger) AddSentryHookWithInfo(dsn string, level Level) (HookInfo, error) {
+	return addSentryHook(l, dsn, level)
+}
+
+func (l logger) AddSentryHookWithTagWithInfo(dsn string, tags map[string]string, level Level) (HookInfo, error) {
+	return addSentryHookWithTag(l, dsn, tags, level)
+}
+
+func (l logger) AddAsyncSentryHookWithInfo(dsn string, level Level) (HookInfo, error) {
+	return addAsyncSentryHook(l, dsn, level)
+}
+
+func (l logger) AddGrayLogHookWithInfo(ip string, port int, extra map[string]interface{}, level Level) (HookInfo, error) {
+	return addGrayLogHook(l, ip, port, extra, level)
+}
+
+func (l logger) AddAsyncGraylogHookWithInfo(ip string, port int, extra map[string]interface{}, level Level) (HookInfo, error) {
+	return addAsyncGraylogHook(l, ip, port, extra, level)
+}
+
+func (l logger) AddHook(hook logrus.Hook, level Level) (HookInfo, error) {
+	if hook == nil {
+		return HookInfo{}, fmt.Errorf("nil hook")
+	}
+	h := &hookEntry{HookInfo: HookInfo{Kind: hookKind(hook), Level: level}, hook: wrapHook(hook)}
+	return l.core.addHook(h).HookInfo, nil
+}
+
+// Hooks returns the hooks registered on the logger. Loggers derived with
+// With, Named or WithContext share the hooks of their parent.
+func (l logger) Hooks() []HookInfo {
+	return l.core.hookInfos()
+}
+
+func (l logger) RemoveHook(id int) error {
+	h, err := l.core.removeHook(id)
+	if err != nil {
+		return err
+	}
+	return h.close()
+}
+
+func (l logger) ReplaceHook(id int, hook logrus.Hook) error {
+	if hook == nil {
+		return fmt.Errorf("nil hook")
+	}
+	old, err := l.core.replaceHook(id, hook)
+	if err != nil {
+		return err
+	}
+	return old.close()
+}
+
+// hookKind returns the kind of the hooks this package knows about.
+func hookKind(hook logrus.Hook) string {
+	switch h := hook.(type) {
+	case *logrus_sentry.SentryHook, sentryHook:
+		return "sentry"
+	case *graylog.GraylogHook, graylogHook:
+		return "graylog"
+	case interface{ kind() string }:
+		return h.kind()
+	}
+	return "custom"
+}
+
+// wrapHook adapts the hooks that need it to the levels of this package.
+func wrapHook(hook logrus.Hook) logrus.Hook {
+	switch h := hook.(type) {
+	case *graylog.GraylogHook:
+		return graylogHook{h}
+	case *logrus_sentry.SentryHook:
+		return sentryHook{SentryHook: h}
+	}
+	return hook
+}
+
+// addHook registers h to be fired for entries at h.Level or above and
+// assigns its id.
+func (c *core) addHook(h *hookEntry) *hookEntry {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+
+	c.lastID++
+	h.ID = c.lastID
+	c.hooks = append(c.hooks, h)
+	c.rebuildHooks()
+	if h.writer != nil {
+		rotateFiles.add(h.writer, h.Target)
+	}
+	return h
+}
+
+// rotateFiles holds the files of the rotate hooks of every logger, so that
+// Reopen reaches the loggers created with NewLogger and NewAccessLogger
+// as well as the standard logger. A file is dropped when its hook is
+// removed or replaced.
+var rotateFiles = &fileRegistry{writers: make(map[*rotatelogs.RotateLogs]string)}
+
+type fileRegistry struct {
+	mu sync.Mutex
+	// writers maps the files to the path of their hook
+	writers map[*rotatelogs.RotateLogs]string
+}
+
+func (r *fileRegistry) add(w *rotatelogs.RotateLogs, path string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.writers[w] = path
+}
+
+func (r *fileRegistry) remove(w *rotatelogs.RotateLogs) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.writers, w)
+}
+
+// reopen closes the files, they are opened again on the next write. This
+// lets external tools like logrotate move or truncate them.
+func (r *fileRegistry) reopen() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var errs []string
+	for w, path := range r.writers {
+		if err := w.Close(); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", path, err))
+		}
+	}
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return fmt.Errorf("can't reopen log files: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// hookInfos returns the description of every registered hook.
+func (c *core) hookInfos() []HookInfo {
+	c.hooksMu.RLock()
+	defer c.hooksMu.RUnlock()
+
+	infos := make([]HookInfo, len(c.hooks))
+	for i, h := range c.hooks {
+		infos[i] = h.HookInfo
+	}
+	return infos
+}
+
+// hookLevel returns the level of the hook with the given id.
+func (c *core) hookLevel(id int) (Level, error) {
+	c.hooksMu.RLock()
+	defer c.hooksMu.RUnlock()
+
+	for _, h := range c.hooks {
+		if h.ID == id {
+			return h.Level, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown hook %d", id)
+}
+
+// setHookLevel changes the level of the hook with the given id.
+func (c *core) setHookLevel(id int, level Level) error {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+
+	for _, h := range c.hooks {
+		if h.ID == id {
+			h.Level = level
+			c.rebuildHooks()
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown hook %d", id)
+}
+
+// removeHook unregisters the hook with the given id and returns it.
+func (c *core) removeHook(id int) (*hookEntry, error) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+
+	for i, h := range c.hooks {
+		if h.ID == id {
+			c.hooks = append(c.hooks[:i:i], c.hooks[i+1:]...)
+			c.rebuildHooks()
+			if h.writer != nil {
+				rotateFiles.remove(h.writer)
+			}
+			return h, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown hook %d", id)
+}
+
+// replaceHook swaps the hook with the given id for hook and returns the
+// old one. Entries logged concurrently go to either of them, never both.
+func (c *core) replaceHook(id int, hook logrus.Hook) (*hookEntry, error) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+
+	for i, h := range c.hooks {
+		if h.ID == id {
+			c.hooks[i] = &hookEntry{
+				HookInfo: HookInfo{ID: id, Kind: hookKind(hook), Target: h.Target, Level: h.Level, Unsampled: h.Unsampled},
+				hook:     wrapHook(hook),
+			}
+			c.rebuildHooks()
+			if h.writer != nil {
+				rotateFiles.remove(h.writer)
+			}
+			return h, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown hook %d", id)
+}
+
+// setHookSampled sets whether the hook with the given id only receives
+// the entries kept by sampling.
+func (c *core) setHookSampled(id int, sampled bool) error {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+
+	for _, h := range c.hooks {
+		if h.ID == id {
+			h.Unsampled = !sampled
+			c.rebuildHooks()
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown hook %d", id)
+}
+
+// rebuildHooks replaces the logrus hooks by the registered hooks, each
+// of them added for its level and above. hooksMu must be held.
+func (c *core) rebuildHooks() {
+	hooks := make(logrus.LevelHooks)
+	unsampled := make(logrus.LevelHooks)
+	for _, h := range c.hooks {
+		for _, level := range convert2logrusLevels(higHerLevel(h.Level)) {
+			hooks[level] = append(hooks[level], h.hook)
+			if h.Unsampled {
+				unsampled[level] = append(unsampled[level], h.hook)
+			}
+		}
+	}
+	c.logger.Hooks = hooks
+	c.unsampled = unsampled
+}
@@ -0,0 +1,158 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+type countHook struct {
+	mu      sync.Mutex
+	entries []string
+	flushed bool
+}
+
+func (h *countHook) Levels() []logrus.Level { return logrus.AllLevels }
+
+func (h *countHook) Fire(entry *logrus.Entry) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.entries = append(h.entries, entry.Message)
+	return nil
+}
+
+func (h *countHook) Flush() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.flushed = true
+}
+
+func (h *countHook) count() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.entries)
+}
+
+func TestHookRegistry(t *testing.T) {
+	l := NewLogger(ioutil.Discard)
+	first, second := &countHook{}, &countHook{}
+
+	info, err := l.AddHook(first, WarnLevel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.ID != 1 || info.Kind != "custom" || info.Level != WarnLevel {
+		t.Fatalf("unexpected hook %+v", info)
+	}
+	if _, err := l.AddHook(second, InfoLevel); err != nil {
+		t.Fatal(err)
+	}
+
+	// derived loggers share the registry of their parent
+	child := l.With("k", "v").Named("child")
+	child.Info("info")
+	child.Error("error")
+	if first.count() != 1 || second.count() != 2 {
+		t.Fatalf("unexpected counts %d, %d", first.count(), second.count())
+	}
+	if hooks := child.Hooks(); len(hooks) != 2 || hooks[1].Level != InfoLevel {
+		t.Fatalf("unexpected hooks %+v", hooks)
+	}
+
+	if err := child.RemoveHook(info.ID); err != nil {
+		t.Fatal(err)
+	}
+	if !first.flushed {
+		t.Fatal("removed hook was not flushed")
+	}
+	l.Error("error")
+	if first.count() != 1 || second.count() != 3 {
+		t.Fatalf("unexpected counts %d, %d", first.count(), second.count())
+	}
+	if err := l.RemoveHook(info.ID); err == nil {
+		t.Fatal("removing an unknown hook should fail")
+	}
+	if hooks := l.Hooks(); len(hooks) != 1 || hooks[0].ID != 2 {
+		t.Fatalf("unexpected hooks %+v", hooks)
+	}
+}
+
+func TestReplaceHook(t *testing.T) {
+	l := NewLogger(ioutil.Discard)
+	old, replacement := &countHook{}, &countHook{}
+
+	info, err := l.AddHook(old, ErrorLevel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.ReplaceHook(info.ID, replacement); err != nil {
+		t.Fatal(err)
+	}
+	if !old.flushed {
+		t.Fatal("replaced hook was not flushed")
+	}
+	l.Warn("warn")
+	l.Error("error")
+	if old.count() != 0 || replacement.count() != 1 {
+		t.Fatalf("unexpected counts %d, %d", old.count(), replacement.count())
+	}
+	if hooks := l.Hooks(); len(hooks) != 1 || hooks[0].ID != info.ID || hooks[0].Level != ErrorLevel {
+		t.Fatalf("unexpected hooks %+v", hooks)
+	}
+	if err := l.ReplaceHook(42, replacement); err == nil {
+		t.Fatal("replacing an unknown hook should fail")
+	}
+
+	path := filepath.Join(t.TempDir(), "app.log")
+	if err := l.AddRotateHookByDay(path, 1, 1, InfoLevel); err != nil {
+		t.Fatal(err)
+	}
+	hooks := l.Hooks()
+	if err := l.ReplaceHook(hooks[1].ID, &countHook{}); err != nil {
+		t.Fatal(err)
+	}
+	if hooks := l.Hooks(); hooks[1].Target != path || hooks[1].Kind != "custom" {
+		t.Fatalf("unexpected hook %+v", hooks[1])
+	}
+}
+
+func TestReplaceGraylogHookByID(t *testing.T) {
+	l := NewLogger(ioutil.Discard)
+
+	info, err := l.AddGrayLogHookWithInfo("127.0.0.1", 12201, nil, WarnLevel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.ID != 1 || info.Kind != "graylog" || info.Target != "127.0.0.1:12201" || info.Level != WarnLevel {
+		t.Fatalf("unexpected hook %+v", info)
+	}
+
+	replacement := &countHook{}
+	if err := l.ReplaceHook(info.ID, replacement); err != nil {
+		t.Fatal(err)
+	}
+	l.Info("info")
+	l.Warn("warn")
+	if replacement.count() != 1 {
+		t.Fatalf("unexpected count %d", replacement.count())
+	}
+	if hooks := l.Hooks(); len(hooks) != 1 || hooks[0].ID != info.ID || hooks[0].Target != info.Target || hooks[0].Kind != "custom" {
+		t.Fatalf("unexpected hooks %+v", hooks)
+	}
+}
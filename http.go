@@ -0,0 +1,319 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// The fields of the entries logged by HTTPMiddleware.
+const (
+	HTTPMethodKey     = "method"
+	HTTPPathKey       = "path"
+	HTTPStatusKey     = "status"
+	HTTPBytesKey      = "bytes"
+	HTTPDurationKey   = "duration"
+	HTTPRemoteAddrKey = "remote_addr"
+	HTTPUserAgentKey  = "user_agent"
+	HTTPRequestIDKey  = "request_id"
+
+	// the fields only needed by the combined format
+	httpProtoKey   = "proto"
+	httpRefererKey = "referer"
+	httpUserKey    = "user"
+	httpStartKey   = "start"
+)
+
+// DefaultRequestIDHeader is the header HTTPMiddleware reads the request ID
+// from when HTTPOptions.RequestIDHeader is not set.
+const DefaultRequestIDHeader = "X-Request-Id"
+
+// HTTPOptions configures HTTPMiddleware, the zero value is ready to use.
+type HTTPOptions struct {
+	// Logger receives the access entries, the standard logger if nil.
+	Logger Logger
+	// Message is the message of the access entries, "http request" if
+	// empty.
+	Message string
+	// RequestIDHeader is the header holding the request ID. A random ID
+	// is generated and set on the response when the request has none.
+	RequestIDHeader string
+	// Level returns the level of the entry of a response with the given
+	// status code. By default 5xx responses are logged at Error, 4xx
+	// responses at Warn and the others at Info.
+	Level func(status int) Level
+	// AccessLog, when set, also receives every request in the Apache
+	// combined log format, see NewAccessLogger.
+	AccessLog Logger
+}
+
+// HTTPMiddleware returns a handler calling next and logging every request
+// with its method, path, status, bytes written, duration, remote address,
+// user agent and request ID as fields.
+//
+// The request context of next carries a Logger with the request_id field,
+// handlers log with it through FromContext(r.Context()).
+func HTTPMiddleware(next http.Handler, opts *HTTPOptions) http.Handler {
+	var o HTTPOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Logger == nil {
+		o.Logger = baseLogger
+	}
+	if o.Message == "" {
+		o.Message = "http request"
+	}
+	if o.RequestIDHeader == "" {
+		o.RequestIDHeader = DefaultRequestIDHeader
+	}
+	if o.Level == nil {
+		o.Level = httpLevel
+	}
+	m := &httpMiddleware{next: next, opts: o, l: loggerOf(o.Logger)}
+	if o.AccessLog != nil {
+		m.al = loggerOf(o.AccessLog)
+	}
+	return m
+}
+
+// httpLevel is the default HTTPOptions.Level.
+func httpLevel(status int) Level {
+	switch {
+	case status >= 500:
+		return ErrorLevel
+	case status >= 400:
+		return WarnLevel
+	}
+	return InfoLevel
+}
+
+type httpMiddleware struct {
+	next http.Handler
+	opts HTTPOptions
+	l    logger
+
+	// al is the logger of opts.AccessLog
+	al logger
+}
+
+func (m *httpMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	id := r.Header.Get(m.opts.RequestIDHeader)
+	if id == "" {
+		id = newRequestID()
+		w.Header().Set(m.opts.RequestIDHeader, id)
+	}
+	rl := loggerOf(m.l.With(HTTPRequestIDKey, id))
+	r = r.WithContext(NewContext(r.Context(), rl))
+	sw := &statusWriter{ResponseWriter: w}
+
+	defer func() {
+		p := recover()
+		if p != nil && sw.status == 0 {
+			sw.status = http.StatusInternalServerError
+		}
+		m.logRequest(rl, r, sw, start)
+		if p != nil {
+			panic(p)
+		}
+	}()
+	m.next.ServeHTTP(sw, r)
+}
+
+func (m *httpMiddleware) logRequest(l logger, r *http.Request, sw *statusWriter, start time.Time) {
+	status := sw.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	fields := []Field{
+		String(HTTPMethodKey, r.Method),
+		String(HTTPPathKey, r.URL.RequestURI()),
+		Int(HTTPStatusKey, status),
+		Int64(HTTPBytesKey, sw.bytes),
+		Duration(HTTPDurationKey, time.Since(start)),
+		String(HTTPRemoteAddrKey, r.RemoteAddr),
+		String(HTTPUserAgentKey, r.UserAgent()),
+	}
+	if level := m.opts.Level(status); l.IsEnabled(level) {
+		l.log(level, m.opts.Message, fields)
+	}
+
+	if m.opts.AccessLog == nil {
+		return
+	}
+	al := m.al
+	if !al.IsEnabled(InfoLevel) {
+		return
+	}
+	fields = append(fields,
+		String(httpProtoKey, r.Proto),
+		String(httpRefererKey, r.Referer()),
+		Any(httpStartKey, start),
+	)
+	if r.URL.User != nil {
+		fields = append(fields, String(httpUserKey, r.URL.User.Username()))
+	} else if user, _, ok := r.BasicAuth(); ok {
+		fields = append(fields, String(httpUserKey, user))
+	}
+	al.log(InfoLevel, m.opts.Message, fields)
+}
+
+// newRequestID returns a random 16 hex digit request ID.
+func newRequestID() string {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	return hex.EncodeToString(b[:])
+}
+
+// statusWriter records the status code and the number of bytes written
+// to a response.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Flush implements http.Flusher when the underlying writer does.
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		if w.status == 0 {
+			w.status = http.StatusOK
+		}
+		f.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker when the underlying writer does.
+func (w *statusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("log: response does not implement http.Hijacker")
+	}
+	if w.status == 0 {
+		w.status = http.StatusSwitchingProtocols
+	}
+	return h.Hijack()
+}
+
+// Unwrap returns the underlying writer for http.ResponseController.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// NewAccessLogger returns a Logger writing only to a file rotated like
+// with AddRotateHookWithFormatter, in the Apache combined log format. It
+// is meant for HTTPOptions.AccessLog:
+//
+//	access, err := log.NewAccessLogger("/var/log/app/access.log", 7*24*time.Hour, 24*time.Hour, "%Y%m%d")
+//	if err != nil {
+//		return err
+//	}
+//	defer access.Shutdown(context.Background())
+//	handler = log.HTTPMiddleware(handler, &log.HTTPOptions{AccessLog: access})
+//
+// The logger is attached to the standard logger: it uses the redaction
+// policy set with SetRedaction unless it is given its own, and it is shut
+// down by Shutdown and the Fatal functions. Its file is reopened on SIGHUP
+// and by Reopen like the files of the other loggers.
+func NewAccessLogger(path string, maxAge, rotateTime time.Duration, format string) (Logger, error) {
+	l := NewLogger(NullOutput).(logger)
+	l.SetSourceMode(SourceNone)
+	if _, err := addRotateHook(l, path, maxAge, rotateTime, format, CombinedLogFormatter, InfoLevel); err != nil {
+		return nil, err
+	}
+	baseLogger.core.attach(l.core)
+	return l, nil
+}
+
+// CombinedLogFormatter formats the entries of HTTPMiddleware in the
+// Apache combined log format:
+//
+//	127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /a.gif HTTP/1.0" 200 2326 "http://example.com/" "Mozilla/5.0"
+var CombinedLogFormatter Formatter = combinedFormatter{}
+
+type combinedFormatter struct{}
+
+func (combinedFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	t, ok := entry.Data[httpStartKey].(time.Time)
+	if !ok {
+		t = entry.Time
+	}
+	host := httpField(entry, HTTPRemoteAddrKey)
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	size := httpField(entry, HTTPBytesKey)
+	if size == "0" {
+		size = "-"
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "%s - %s [%s] %q %s %s %q %q\n",
+		dash(host),
+		dash(httpField(entry, httpUserKey)),
+		t.Format("02/Jan/2006:15:04:05 -0700"),
+		httpField(entry, HTTPMethodKey)+" "+httpField(entry, HTTPPathKey)+" "+httpField(entry, httpProtoKey),
+		dash(httpField(entry, HTTPStatusKey)),
+		dash(size),
+		dash(httpField(entry, httpRefererKey)),
+		dash(httpField(entry, HTTPUserAgentKey)),
+	)
+	return b.Bytes(), nil
+}
+
+// httpField returns the field key of entry as a string, empty if missing.
+func httpField(entry *logrus.Entry, key string) string {
+	v, ok := entry.Data[key]
+	if !ok || v == nil {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
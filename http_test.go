@@ -0,0 +1,179 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestHTTPMiddleware(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLogger(&buf)
+	h := HTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		FromContext(r.Context()).Info("handling")
+		http.NotFound(w, r)
+	}), &HTTPOptions{Logger: l})
+
+	r := httptest.NewRequest("GET", "/missing?q=1", nil)
+	r.Header.Set("User-Agent", "curl/8.0")
+	r.Header.Set(DefaultRequestIDHeader, "abc123")
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("unexpected entries %q", lines)
+	}
+	if !strings.Contains(lines[0], "msg=handling") || !strings.Contains(lines[0], "request_id=abc123") {
+		t.Fatalf("request logger not in context: %q", lines[0])
+	}
+	for _, want := range []string{"level=warning", `msg="http request"`, "method=GET", `path="/missing?q=1"`, "status=404", "bytes=19", "duration=", "remote_addr=", "user_agent=curl/8.0", "request_id=abc123"} {
+		if !strings.Contains(lines[1], want) {
+			t.Fatalf("%s not found in %q", want, lines[1])
+		}
+	}
+}
+
+func TestHTTPMiddlewareRequestID(t *testing.T) {
+	l := NewLogger(NullOutput)
+	h := HTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), &HTTPOptions{Logger: l})
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	if id := w.Header().Get(DefaultRequestIDHeader); len(id) != 16 {
+		t.Fatalf("unexpected generated request ID %q", id)
+	}
+}
+
+func TestHTTPMiddlewarePanic(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLogger(&buf)
+	h := HTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}), &HTTPOptions{Logger: l})
+
+	defer func() {
+		if p := recover(); p != http.ErrAbortHandler {
+			t.Fatalf("unexpected panic %v", p)
+		}
+		if out := buf.String(); !strings.Contains(out, "level=error") || !strings.Contains(out, "status=500") {
+			t.Fatalf("unexpected output %q", out)
+		}
+	}()
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+}
+
+func TestAccessLogger(t *testing.T) {
+	dir, err := ioutil.TempDir("", "access")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "access.log")
+	access, err := NewAccessLogger(path, time.Hour, time.Hour, "%Y%m%d%H")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := HTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello"))
+	}), &HTTPOptions{Logger: NewLogger(NullOutput), AccessLog: access})
+
+	r := httptest.NewRequest("GET", "/index.html", nil)
+	r.SetBasicAuth("frank", "secret")
+	r.Header.Set("Referer", "http://example.com/")
+	r.Header.Set("User-Agent", "Mozilla/5.0")
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	if err := access.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := regexp.MustCompile(`^192\.0\.2\.1 - frank \[\d\d/\w{3}/\d{4}:\d\d:\d\d:\d\d [-+]\d{4}\] "GET /index.html HTTP/1.1" 200 5 "http://example.com/" "Mozilla/5.0"\n$`)
+	if !want.Match(b) {
+		t.Fatalf("unexpected access log %q", b)
+	}
+}
+
+func TestAccessLoggerAttached(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "access.log")
+	access, err := NewAccessLogger(path, time.Hour, time.Hour, "%Y%m%d%H")
+	if err != nil {
+		t.Fatal(err)
+	}
+	flushed := &countHook{}
+	if _, err := access.AddHook(flushed, InfoLevel); err != nil {
+		t.Fatal(err)
+	}
+	SetRedaction(&RedactionPolicy{Redactors: []Redactor{RedactEmails}})
+	defer SetRedaction(nil)
+
+	h := HTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), &HTTPOptions{Logger: NewLogger(NullOutput), AccessLog: access})
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/alice@example.com", nil))
+	if err := Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !flushed.flushed {
+		t.Fatal("access logger was not shut down with the standard logger")
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"GET /users/a***@example.com HTTP/1.1"`) {
+		t.Fatalf("path not redacted in %q", b)
+	}
+}
+
+func TestCombinedLogFormatterDashes(t *testing.T) {
+	entry := &logrus.Entry{Time: time.Date(2000, 10, 10, 13, 55, 36, 0, time.UTC), Data: logrus.Fields{
+		HTTPRemoteAddrKey: "127.0.0.1:1234",
+		HTTPMethodKey:     "GET",
+		HTTPPathKey:       "/",
+		httpProtoKey:      "HTTP/1.0",
+		HTTPStatusKey:     200,
+		HTTPBytesKey:      int64(0),
+		httpRefererKey:    "",
+		HTTPUserAgentKey:  "",
+	}}
+	b, err := CombinedLogFormatter.Format(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `127.0.0.1 - - [10/Oct/2000:13:55:36 +0000] "GET / HTTP/1.0" 200 - "-" "-"` + "\n"; string(b) != want {
+		t.Fatalf("got %q, want %q", b, want)
+	}
+}
+
+func TestHTTPMiddlewareOtherLogger(t *testing.T) {
+	var buf bytes.Buffer
+	h := HTTPMiddleware(http.NotFoundHandler(), &HTTPOptions{Logger: otherLogger{NewLogger(&buf)}})
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/missing", nil))
+	if out := buf.String(); !strings.Contains(out, "status=404") || !strings.Contains(out, "request_id=") {
+		t.Fatalf("unexpected output %q", out)
+	}
+}
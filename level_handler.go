@@ -0,0 +1,260 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// globalTarget identifies the level of the logger itself in levelHandler,
+// hook ids start at 1.
+const globalTarget = 0
+
+// LevelHandler returns an http.Handler to view and change the levels of the
+// standard logger at runtime.
+//
+// GET returns the global level and the level of every hook:
+//
+//	{"level":"info","hooks":[{"id":1,"kind":"rotate","target":"/var/log/app.log","level":"error"}]}
+//
+// PUT and POST change the global level, or the level of a hook when its id
+// is given. With a timeout the previous level is restored once it elapses:
+//
+//	{"level":"debug","hook":1,"timeout":"10m"}
+//
+// The parameters can be sent as a JSON body or as form values.
+func LevelHandler() http.Handler {
+	return newLevelHandler(baseLogger)
+}
+
+type levelHandler struct {
+	l logger
+
+	mu      sync.Mutex
+	reverts map[int]*levelRevert
+}
+
+type levelRevert struct {
+	timer *time.Timer
+	level Level
+	at    time.Time
+}
+
+type levelRequest struct {
+	Level   *Level `json:"level"`
+	Hook    int    `json:"hook"`
+	Timeout string `json:"timeout"`
+}
+
+type levelState struct {
+	Level    Level            `json:"level"`
+	RevertAt *time.Time       `json:"revert_at,omitempty"`
+	Hooks    []hookLevelState `json:"hooks"`
+}
+
+type hookLevelState struct {
+	HookInfo
+	RevertAt *time.Time `json:"revert_at,omitempty"`
+}
+
+func newLevelHandler(l logger) *levelHandler {
+	return &levelHandler{l: l, reverts: make(map[int]*levelRevert)}
+}
+
+func (h *levelHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut, http.MethodPost:
+		req, err := parseLevelRequest(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var timeout time.Duration
+		if req.Timeout != "" {
+			if timeout, err = time.ParseDuration(req.Timeout); err != nil {
+				http.Error(w, fmt.Sprintf("invalid timeout: %v", err), http.StatusBadRequest)
+				return
+			}
+		}
+		if err := h.set(req.Hook, *req.Level, timeout); err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, PUT, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.state())
+}
+
+func parseLevelRequest(r *http.Request) (*levelRequest, error) {
+	req := &levelRequest{}
+	if ct, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); ct == "application/json" {
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			return nil, fmt.Errorf("invalid body: %v", err)
+		}
+	} else {
+		if v := r.FormValue("level"); v != "" {
+			level, err := ParseLevel(v)
+			if err != nil {
+				return nil, err
+			}
+			req.Level = &level
+		}
+		if v := r.FormValue("hook"); v != "" {
+			id, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid hook: %v", err)
+			}
+			req.Hook = id
+		}
+		req.Timeout = r.FormValue("timeout")
+	}
+	if req.Level == nil {
+		return nil, fmt.Errorf("missing level")
+	}
+	return req, nil
+}
+
+func (h *levelHandler) level(target int) (Level, error) {
+	if target == globalTarget {
+		return h.l.core.level(), nil
+	}
+	return h.l.core.hookLevel(target)
+}
+
+func (h *levelHandler) setLevel(target int, level Level) error {
+	if target == globalTarget {
+		h.l.core.setLevel(level)
+		return nil
+	}
+	return h.l.core.setHookLevel(target, level)
+}
+
+// set changes the level of target. A pending revert of the same target is
+// cancelled but the level it would have restored is kept, so that nested
+// changes during an incident still fall back to the original level.
+func (h *levelHandler) set(target int, level Level, timeout time.Duration) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	prev, err := h.level(target)
+	if err != nil {
+		return err
+	}
+	if r, ok := h.reverts[target]; ok {
+		r.timer.Stop()
+		prev = r.level
+		delete(h.reverts, target)
+	}
+	msg := "log level changed"
+	if timeout > 0 {
+		msg = fmt.Sprintf("log level changed, reverting to %s in %s", prev, timeout)
+	}
+	if err := h.change(target, level, msg); err != nil {
+		return err
+	}
+
+	if timeout > 0 {
+		r := &levelRevert{level: prev, at: time.Now().Add(timeout)}
+		r.timer = time.AfterFunc(timeout, func() { h.revert(target, r) })
+		h.reverts[target] = r
+	}
+	return nil
+}
+
+// change sets the level of target and logs msg about it. The entry is
+// logged at Info, or at Error when the logger drops Info entries before or
+// after the change, and it is written before the change when the logger
+// writes it then, so that raising the level does not drop it. It goes to
+// stderr when the logger drops it both before and after the change.
+func (h *levelHandler) change(target int, level Level, msg string) error {
+	prev := h.l.core.level()
+	next := prev
+	if target == globalTarget {
+		next = level
+	}
+	entryLevel := InfoLevel
+	if prev < entryLevel || next < entryLevel {
+		entryLevel = ErrorLevel
+	}
+	l := h.l.With("target", targetName(target)).With("level", level.String())
+
+	switch {
+	case entryLevel <= prev:
+		l.Log(entryLevel, msg)
+		return h.setLevel(target, level)
+	case entryLevel <= next:
+		if err := h.setLevel(target, level); err != nil {
+			return err
+		}
+		l.Log(entryLevel, msg)
+	default:
+		if err := h.setLevel(target, level); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "%s, target=%s level=%s\n", msg, targetName(target), level)
+	}
+	return nil
+}
+
+func (h *levelHandler) revert(target int, r *levelRevert) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.reverts[target] != r {
+		// superseded by a later change
+		return
+	}
+	delete(h.reverts, target)
+	if err := h.change(target, r.level, "log level reverted"); err != nil {
+		h.l.Warnf("can't revert log level of %s: %v", targetName(target), err)
+	}
+}
+
+func (h *levelHandler) state() levelState {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	st := levelState{Level: h.l.core.level(), Hooks: []hookLevelState{}}
+	if r, ok := h.reverts[globalTarget]; ok {
+		st.RevertAt = &r.at
+	}
+	for _, info := range h.l.core.hookInfos() {
+		hs := hookLevelState{HookInfo: info}
+		if r, ok := h.reverts[info.ID]; ok {
+			hs.RevertAt = &r.at
+		}
+		st.Hooks = append(st.Hooks, hs)
+	}
+	return st
+}
+
+func targetName(target int) string {
+	if target == globalTarget {
+		return "global"
+	}
+	return fmt.Sprintf("hook %d", target)
+}
@@ -0,0 +1,152 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func doLevelRequest(t *testing.T, h http.Handler, method, body string) levelState {
+	req := httptest.NewRequest(method, "/", strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("%s %s: status %d: %s", method, body, rec.Code, rec.Body.String())
+	}
+	var st levelState
+	if err := json.Unmarshal(rec.Body.Bytes(), &st); err != nil {
+		t.Fatal(err)
+	}
+	return st
+}
+
+func TestLevelHandler(t *testing.T) {
+	l := NewLogger(ioutil.Discard).(logger)
+	l.core.setLevel(InfoLevel)
+	if err := l.AddRotateHookByDay(filepath.Join(t.TempDir(), "app.log"), 1, 1, ErrorLevel); err != nil {
+		t.Fatal(err)
+	}
+	h := newLevelHandler(l)
+
+	st := doLevelRequest(t, h, http.MethodGet, "")
+	if st.Level != InfoLevel || len(st.Hooks) != 1 || st.Hooks[0].Level != ErrorLevel || st.Hooks[0].Kind != "rotate" {
+		t.Fatalf("unexpected state %+v", st)
+	}
+
+	st = doLevelRequest(t, h, http.MethodPut, `{"level":"debug"}`)
+	if st.Level != DebugLevel || l.core.level() != DebugLevel {
+		t.Fatalf("unexpected state %+v", st)
+	}
+
+	st = doLevelRequest(t, h, http.MethodPost, `{"level":"warn","hook":1}`)
+	if st.Hooks[0].Level != WarnLevel {
+		t.Fatalf("unexpected state %+v", st)
+	}
+	if len(l.entry.Logger.Hooks[logrus.WarnLevel]) != 1 || len(l.entry.Logger.Hooks[logrus.InfoLevel]) != 0 {
+		t.Fatal("hooks were not rebuilt for the new level")
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/?level=debug&hook=42", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("status %d != %d", rec.Code, http.StatusNotFound)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/?level=loud", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status %d != %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestLevelHandlerLogsRaise(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLogger(&buf).(logger)
+	l.core.setLevel(InfoLevel)
+	h := newLevelHandler(l)
+
+	doLevelRequest(t, h, http.MethodPut, `{"level":"warn"}`)
+	if out := buf.String(); !strings.Contains(out, `msg="log level changed"`) || !strings.Contains(out, "target=global") {
+		t.Fatalf("change to warn not logged in %q", out)
+	}
+
+	buf.Reset()
+	doLevelRequest(t, h, http.MethodPut, `{"level":"info"}`)
+	if out := buf.String(); !strings.Contains(out, `msg="log level changed"`) || !strings.Contains(out, "level=error") {
+		t.Fatalf("change back to info not logged in %q", out)
+	}
+}
+
+func TestLevelHandlerRevert(t *testing.T) {
+	l := NewLogger(ioutil.Discard).(logger)
+	l.core.setLevel(InfoLevel)
+	h := newLevelHandler(l)
+
+	st := doLevelRequest(t, h, http.MethodPut, `{"level":"debug","timeout":"50ms"}`)
+	if st.Level != DebugLevel || st.RevertAt == nil {
+		t.Fatalf("unexpected state %+v", st)
+	}
+	// a second change keeps the original level to revert to
+	doLevelRequest(t, h, http.MethodPut, `{"level":"trace","timeout":"50ms"}`)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for l.core.level() != InfoLevel {
+		if time.Now().After(deadline) {
+			t.Fatalf("level %s was not reverted", l.core.level())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if st := doLevelRequest(t, h, http.MethodGet, ""); st.RevertAt != nil {
+		t.Fatalf("unexpected state %+v", st)
+	}
+}
+
+func TestLevelChangeWhileLogging(t *testing.T) {
+	l := NewLogger(ioutil.Discard).(logger)
+	h := newLevelHandler(l)
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				l.Debug("concurrent")
+			}
+		}
+	}()
+	for _, level := range []string{"info", "debug", "warn", "trace"} {
+		doLevelRequest(t, h, http.MethodPut, `{"level":"`+level+`"}`)
+	}
+	close(stop)
+	wg.Wait()
+}
@@ -14,6 +14,8 @@
 package log
 
 import (
+	"bytes"
+	"context"
 	"flag"
 	"fmt"
 	"io"
@@ -22,16 +24,16 @@ import (
 	"net/url"
 	"os"
 	"path"
-	"runtime"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
-	"bytes"
-
-	"github.com/evalphobia/logrus_sentry"
+	"github.com/getsentry/raven-go"
 	"github.com/lestrrat/go-file-rotatelogs"
 	"github.com/lwhile/logrus-graylog-hook"
+	"github.com/pkg/errors"
 	"github.com/rifflock/lfshook"
 	"github.com/sirupsen/logrus"
 )
@@ -65,37 +67,44 @@ type Formatter interface {
 	logrus.Formatter
 }
 
-// PFormatter :
-type PFormatter struct{}
+var dftFormatter = traceFormatter{&logrus.TextFormatter{DisableColors: true}}
 
-// Format implement Formatter interface
-// time [Level][source] message
-func (p *PFormatter) Format(entry *logrus.Entry) ([]byte, error) {
-	var b bytes.Buffer
+// traceFormatter wraps a logrus formatter to render TraceLevel, which the
+// vendored logrus does not know and formats as "unknown".
+type traceFormatter struct {
+	logrus.Formatter
+}
 
-	// write prefix message to buffer
-	preFixFmt := "%s [%s][%s] "
-	prefix := fmt.Sprintf(preFixFmt, entry.Time.Format("2006-01-02 15:04:05"), entry.Level.String(), entry.Data["source"].(string))
-	if _, err := b.WriteString(prefix); err != nil {
-		return nil, err
-	}
+// traceLevelTexts are the renderings of TraceLevel by the logrus text and
+// JSON formatters and their replacement.
+var traceLevelTexts = []struct{ unknown, trace []byte }{
+	{[]byte("level=unknown"), []byte("level=trace")},
+	{[]byte(`"level":"unknown"`), []byte(`"level":"trace"`)},
+	{[]byte("UNKN"), []byte("TRAC")},
+}
 
-	// write log content to buffer
-	if _, err := b.WriteString(entry.Message); err != nil {
-		return nil, err
+// Format implements logrus.Formatter.
+func (f traceFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	b, err := f.Formatter.Format(entry)
+	if err != nil || entry.Level != logrus.Level(TraceLevel) {
+		return b, err
 	}
-
-	if _, err := b.WriteString("\n"); err != nil {
-		return nil, err
+	for _, t := range traceLevelTexts {
+		if i := bytes.Index(b, t.unknown); i >= 0 {
+			return append(append(b[:i:i], t.trace...), b[i+len(t.unknown):]...), nil
+		}
 	}
-
-	return b.Bytes(), nil
+	return b, nil
 }
 
-// PrefixedFormatter is a default variable that exported to package user
-var PrefixedFormatter = &PFormatter{}
-
-var dftFormatter = &logrus.TextFormatter{DisableColors: true}
+// newLogrusLogger returns a logrus logger writing to w with the text
+// formatter.
+func newLogrusLogger(w io.Writer) *logrus.Logger {
+	l := logrus.New()
+	l.Out = w
+	l.Formatter = traceFormatter{l.Formatter}
+	return l
+}
 
 // type dftFORMATTER struct {
 // 	logrus.TextFormatter
@@ -118,11 +127,11 @@ func (nw *nullOutput) Write(p []byte) (int, error) {
 
 // Set implements flag.Value.
 func (f levelFlag) Set(level string) error {
-	l, err := logrus.ParseLevel(level)
+	l, err := ParseLevel(level)
 	if err != nil {
 		return err
 	}
-	origLogger.Level = l
+	baseLogger.core.setLevel(l)
 	return nil
 }
 
@@ -133,7 +142,7 @@ var setSyslogFormatter func(string, string) error
 var setEventlogFormatter func(string, bool) error
 
 func setJSONFormatter() {
-	origLogger.Formatter = &logrus.JSONFormatter{}
+	origLogger.Formatter = traceFormatter{&logrus.JSONFormatter{}}
 }
 
 type logFormatFlag url.URL
@@ -157,6 +166,17 @@ func (f logFormatFlag) Set(format string) error {
 	if jsonq == "true" {
 		setJSONFormatter()
 	}
+	switch format := u.Query().Get("format"); format {
+	case "":
+	case "json":
+		setJSONFormatter()
+	case "console":
+		origLogger.Formatter = &ConsoleFormatter{}
+	case "logfmt":
+		origLogger.Formatter = &LogfmtFormatter{}
+	default:
+		return fmt.Errorf("unsupported log format %q", format)
+	}
 
 	switch u.Opaque {
 	case "syslog":
@@ -199,38 +219,66 @@ func AddFlags(fs *flag.FlagSet) {
 	fs.Var(
 		levelFlag(origLogger.Level.String()),
 		"log.level",
-		"Only log messages with the given severity or above. Valid levels: [debug, info, warn, error, fatal]",
+		"Only log messages with the given severity or above. Valid levels: [trace, debug, info, warn, error, fatal, panic]",
 	)
 	fs.Var(
 		logFormatFlag(url.URL{Scheme: "logger", Opaque: "stderr"}),
 		"log.format",
-		`Set the log target and format. Example: "logger:syslog?appname=bob&local=7" or "logger:stdout?json=true"`,
+		`Set the log target and format. Example: "logger:syslog?appname=bob&local=7", "logger:stdout?json=true" or "logger:stderr?format=console"`,
 	)
 }
 
 // Logger is the interface for loggers used in the Prometheus components.
 type Logger interface {
+	Trace(...interface{})
+	Traceln(...interface{})
+	Tracef(string, ...interface{})
+	Tracew(msg string, keysAndValues ...interface{})
+
 	Debug(...interface{})
 	Debugln(...interface{})
 	Debugf(string, ...interface{})
+	Debugw(msg string, keysAndValues ...interface{})
 
 	Info(...interface{})
 	Infoln(...interface{})
 	Infof(string, ...interface{})
+	Infow(msg string, keysAndValues ...interface{})
 
 	Warn(...interface{})
 	Warnln(...interface{})
 	Warnf(string, ...interface{})
+	Warnw(msg string, keysAndValues ...interface{})
 
 	Error(...interface{})
 	Errorln(...interface{})
 	Errorf(string, ...interface{})
+	Errorw(msg string, keysAndValues ...interface{})
 
 	Fatal(...interface{})
 	Fatalln(...interface{})
 	Fatalf(string, ...interface{})
+	Fatalw(msg string, keysAndValues ...interface{})
+
+	Panic(...interface{})
+	Panicln(...interface{})
+	Panicf(string, ...interface{})
+	Panicw(msg string, keysAndValues ...interface{})
+
+	Log(level Level, msg string, fields ...Field)
+
+	IsEnabled(level Level) bool
+	TraceFn(fn func() string)
+	DebugFn(fn func() string)
+	InfoFn(fn func() string)
+	WarnFn(fn func() string)
+	ErrorFn(fn func() string)
 
 	With(key string, value interface{}) Logger
+	WithFields(fields map[string]interface{}) Logger
+	WithError(err error) Logger
+	WithContext(ctx context.Context) Logger
+	Named(name string) Logger
 
 	AddRotateHook(path string, maxAge, rotateTime time.Duration, format string, level Level) error
 	AddRotateHookWithFormatter(path string, maxAge, rotateTime time.Duration, format string, formatter Formatter, level Level) error
@@ -250,26 +298,291 @@ type Logger interface {
 	AddAsyncGraylogHook(ip string, port int, extra map[string]interface{}, level Level) error
 	GrayAsyncHookFlush()
 
+	AddRotateHookWithInfo(path string, maxAge, rotateTime time.Duration, format string, level Level) (HookInfo, error)
+	AddRotateHookWithFormatterWithInfo(path string, maxAge, rotateTime time.Duration, format string, formatter Formatter, level Level) (HookInfo, error)
+	AddRotateHookByDayWithInfo(path string, maxAge, rotateDay int, level Level) (HookInfo, error)
+	AddRotateHookByDayWithFormatterWithInfo(path string, maxAge, rotateDay int, formatter Formatter, level Level) (HookInfo, error)
+	AddRotateHookByHourWithInfo(path string, maxAge, rotateHour int, level Level) (HookInfo, error)
+	AddRotateHookByHourWithFormatterWithInfo(path string, maxAge, rotateHour int, formatter Formatter, level Level) (HookInfo, error)
+	AddSentryHookWithInfo(dsn string, level Level) (HookInfo, error)
+	AddSentryHookWithTagWithInfo(dsn string, tags map[string]string, level Level) (HookInfo, error)
+	AddAsyncSentryHookWithInfo(dsn string, level Level) (HookInfo, error)
+	AddGrayLogHookWithInfo(ip string, port int, extra map[string]interface{}, level Level) (HookInfo, error)
+	AddAsyncGraylogHookWithInfo(ip string, port int, extra map[string]interface{}, level Level) (HookInfo, error)
+
+	AddHook(hook logrus.Hook, level Level) (HookInfo, error)
+	Hooks() []HookInfo
+	RemoveHook(id int) error
+	ReplaceHook(id int, hook logrus.Hook) error
+	SetHookSampled(id int, sampled bool) error
+
+	Shutdown(ctx context.Context) error
+
 	SetOutput(w io.Writer)
+	SetLevel(level Level)
+	SetExitFunc(fn func(code int))
+	SetSourceMode(mode SourceMode)
+	SetStacktraceLevel(level Level)
+	SetSampling(level Level, policy *SamplingPolicy)
+	SetDedup(window time.Duration)
+	SetAsync(opts *AsyncOptions)
+	AsyncStats() AsyncStats
+	SetRedaction(policy *RedactionPolicy)
+	WithCallerSkip(n int) Logger
+	Writer(level Level) io.WriteCloser
 }
 
 type logger struct {
-	entry     *logrus.Entry
-	grayHooks []*graylog.GraylogHook
+	entry *logrus.Entry
+	ctx   context.Context
+	core  *core
+	name  *loggerName
+
+	// skip is the number of extra frames to skip to find the caller
+	skip int
 }
 
-// sourced adds a source field to the logger that contains
-// the file name and line where the logging happened.
+// callerDepth is the number of frames between sourced and the code that
+// called one of the logging methods: sourced, emit, log or logf and the
+// method itself.
+const callerDepth = 4
+
+// sourced returns a new entry carrying the logger's fields, the fields
+// extracted from its context and a source field that contains the file
+// name and line where the logging happened. It must only be called by log.
 func (l logger) sourced() *logrus.Entry {
-	_, file, line, ok := runtime.Caller(2)
-	if !ok {
-		file = "<???>"
-		line = 1
-	} else {
-		slash := strings.LastIndex(file, "/")
-		file = file[slash+1:]
+	entry := l.newEntry()
+	if mode := l.core.sourceMode(); mode&sourcePathMask != SourceNone {
+		source, function := caller(callerDepth+l.skip, mode)
+		entry.Data["source"] = source
+		if function != "" {
+			entry.Data[FuncKey] = function
+		}
+	}
+	return entry
+}
+
+// newEntry returns a new entry carrying the logger's fields and the fields
+// extracted from its context.
+func (l logger) newEntry() *logrus.Entry {
+	var ctxFields logrus.Fields
+	if l.ctx != nil {
+		ctxFields = contextFields(l.ctx)
+	}
+	data := make(logrus.Fields, len(l.entry.Data)+len(ctxFields)+2)
+	for k, v := range l.entry.Data {
+		data[k] = v
+	}
+	for k, v := range ctxFields {
+		data[k] = v
+	}
+	return &logrus.Entry{Logger: l.entry.Logger, Data: data}
+}
+
+// IsEnabled reports whether an entry at level would be logged. It is
+// cheap enough to guard the construction of expensive arguments.
+func (l logger) IsEnabled(level Level) bool {
+	if l.name != nil {
+		if named, ok := l.name.level(); ok {
+			return level <= named
+		}
+	}
+	return level <= l.core.level()
+}
+
+// log writes msg with fields at level. The caller is responsible for
+// checking the level first so that disabled entries cost nothing.
+func (l logger) log(level Level, msg string, fields []Field) {
+	sampled := l.core.sample(level, msg)
+	if !sampled && !l.core.hasUnsampledHooks(level) {
+		return
+	}
+	l.emit(level, msg, fields, sampled)
+}
+
+// logf is log for the printf style methods, entries are sampled by format
+// and only formatted when they are written.
+func (l logger) logf(level Level, format string, args []interface{}) {
+	sampled := l.core.sample(level, format)
+	if !sampled && !l.core.hasUnsampledHooks(level) {
+		return
+	}
+	l.emit(level, fmt.Sprintf(format, args...), nil, sampled)
+}
+
+// emit builds the entry and writes it, or only fires the hooks exempted
+// from sampling when it was sampled out. It must only be called by log
+// and logf.
+func (l logger) emit(level Level, msg string, fields []Field, sampled bool) {
+	entry := l.sourced()
+	for _, f := range fields {
+		entry.Data[f.Key] = f.dataValue()
+	}
+	if l.core.stackEnabled(level) {
+		// skip emit, log and the method that called it
+		entry.Data[StacktraceKey] = stacktrace(entry, 3+l.skip)
+	}
+	if !sampled {
+		l.core.fireUnsampled(entry, level, msg)
+		return
+	}
+	l.core.write(entry, level, msg)
+}
+
+// core holds the state shared by a logger and all the loggers derived
+// from it with With, WithFields and friends.
+type core struct {
+	logger *logrus.Logger
+
+	// mu serializes writes to the output
+	mu sync.Mutex
+
+	// hooksMu guards hooks and logger.Hooks which is rebuilt from them
+	hooksMu sync.RWMutex
+	hooks   []*hookEntry
+	lastID  int
+
+	// unsampled holds the hooks exempted from sampling, rebuilt with
+	// logger.Hooks
+	unsampled logrus.LevelHooks
+
+	// sampler is the *sampler in use, nil when sampling is disabled,
+	// samplingMu serializes its replacement
+	sampler    atomic.Value
+	samplingMu sync.Mutex
+
+	// dedup is the *deduper in use, nil when deduplication is disabled
+	dedup atomic.Value
+
+	// async is the *dispatcher in use, nil when entries are written
+	// synchronously, asyncMu serializes its replacement
+	async   atomic.Value
+	asyncMu sync.Mutex
+
+	// source is the SourceMode and stack the stacktrace Level, both
+	// accessed atomically
+	source uint32
+	stack  uint32
+
+	// redactor is the *redactor in use, nil when redaction is disabled
+	redactor atomic.Value
+
+	// exit is the func(int) called after a Fatal entry, nil for
+	// logrus.Exit
+	exit atomic.Value
+
+	// parent is the core the access loggers are attached to, its
+	// redactor applies when the core has none. children lists the
+	// attached cores, they are shut down with their parent.
+	parent     *core
+	childrenMu sync.Mutex
+	children   []*core
+}
+
+func newCore(l *logrus.Logger) *core {
+	return &core{logger: l, stack: uint32(FatalLevel)}
+}
+
+// attach ties the lifecycle of child to c: child follows the redaction
+// policy of c unless it has its own and is shut down along with c.
+func (c *core) attach(child *core) {
+	c.childrenMu.Lock()
+	defer c.childrenMu.Unlock()
+	child.parent = c
+	c.children = append(c.children, child)
+}
+
+func (c *core) level() Level {
+	return Level(atomic.LoadUint32((*uint32)(&c.logger.Level)))
+}
+
+func (c *core) setLevel(level Level) {
+	c.logger.SetLevel(logrus.Level(level))
+}
+
+// write redacts the entry and writes it unless it repeats the previous
+// one and deduplication is enabled. With an async dispatcher the entry is queued,
+// but Panic and Fatal entries are written right away once the queue is
+// drained.
+func (c *core) write(entry *logrus.Entry, level Level, msg string) {
+	entry.Time = time.Now()
+	entry.Level = logrus.Level(level)
+	entry.Message = msg
+	c.redact(entry)
+
+	if d, _ := c.dedup.Load().(*deduper); d != nil && !d.admit(c, entry) {
+		return
 	}
-	return l.entry.WithField("source", fmt.Sprintf("%s:%d", file, line))
+	if a, _ := c.async.Load().(*dispatcher); a != nil {
+		if level > FatalLevel {
+			if a.enqueue(entry) {
+				return
+			}
+		} else {
+			ctx, cancel := context.WithTimeout(context.Background(), FatalShutdownTimeout)
+			a.drain(ctx)
+			cancel()
+		}
+	}
+	c.output(entry)
+}
+
+// output fires the hooks, formats the entry and writes it to the output.
+// It mirrors logrus' Entry.log which cannot be called from here and which
+// would check the level a second time.
+func (c *core) output(entry *logrus.Entry) {
+	c.hooksMu.RLock()
+	err := entry.Logger.Hooks.Fire(entry.Level, entry)
+	c.hooksMu.RUnlock()
+	if err != nil {
+		c.hookError(err)
+	}
+
+	serialized, err := entry.Logger.Formatter.Format(entry)
+	c.mu.Lock()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to obtain reader, %v\n", err)
+	} else if _, err = entry.Logger.Out.Write(serialized); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write to log, %v\n", err)
+	}
+	c.mu.Unlock()
+
+	if entry.Level == logrus.FatalLevel {
+		c.fatalExit()
+	}
+}
+
+// fatalExit shuts the logger down, giving the hooks a chance to deliver
+// the fatal entry, and exits with status 1. The Fatal methods call it even
+// when level Fatal is disabled, like logrus always exits.
+func (c *core) fatalExit() {
+	ctx, cancel := context.WithTimeout(context.Background(), FatalShutdownTimeout)
+	if err := c.shutdown(ctx); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to shut down logger, %v\n", err)
+	}
+	cancel()
+	c.exitFunc()(1)
+}
+
+func (c *core) hookError(err error) {
+	c.mu.Lock()
+	fmt.Fprintf(os.Stderr, "Failed to fire hook: %v\n", err)
+	c.mu.Unlock()
+}
+
+// panicValue returns the value to panic with after logging args: the
+// original value when there is a single argument, the message otherwise.
+func panicValue(msg string, args []interface{}) interface{} {
+	if len(args) == 1 {
+		return args[0]
+	}
+	return msg
+}
+
+// sprintln formats args like fmt.Sprintln without the trailing newline.
+func sprintln(args ...interface{}) string {
+	msg := fmt.Sprintln(args...)
+	return msg[:len(msg)-1]
 }
 
 // Level represent trigger level of log
@@ -282,9 +595,13 @@ const (
 	WarnLevel
 	InfoLevel
 	DebugLevel
+	// TraceLevel is for very chatty tracing, below DebugLevel. The vendored
+	// logrus does not know this level, the formatters of the loggers of
+	// this package render it as "trace".
+	TraceLevel
 )
 
-var levelSlice = []Level{DebugLevel, InfoLevel, WarnLevel, ErrorLevel, FatalLevel, PanicLevel}
+var levelSlice = []Level{TraceLevel, DebugLevel, InfoLevel, WarnLevel, ErrorLevel, FatalLevel, PanicLevel}
 
 func (l Level) String() (s string) {
 	switch {
@@ -300,17 +617,43 @@ func (l Level) String() (s string) {
 		s = "panic"
 	case l == FatalLevel:
 		s = "fatal"
+	case l == TraceLevel:
+		s = "trace"
 	}
 	return
 }
 
+// MarshalText implements encoding.TextMarshaler.
+func (l Level) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (l *Level) UnmarshalText(text []byte) error {
+	level, err := ParseLevel(string(text))
+	if err != nil {
+		return err
+	}
+	*l = level
+	return nil
+}
+
+// ParseLevel takes a string level and returns the Level constant.
+func ParseLevel(level string) (Level, error) {
+	if strings.ToLower(level) == "trace" {
+		return TraceLevel, nil
+	}
+	l, err := logrus.ParseLevel(level)
+	return Level(l), err
+}
+
 var origLogger = newOrigLogger()
-var baseLogger = logger{entry: logrus.NewEntry(origLogger)}
+var baseLogger = logger{entry: logrus.NewEntry(origLogger), core: newCore(origLogger)}
 
 func newOrigLogger() *logrus.Logger {
 	return &logrus.Logger{
 		Out:       os.Stdout,
-		Formatter: new(logrus.TextFormatter),
+		Formatter: traceFormatter{new(logrus.TextFormatter)},
 		Hooks:     make(logrus.LevelHooks),
 		Level:     logrus.DebugLevel,
 	}
@@ -332,6 +675,8 @@ func convert2logrusLevels(levels []Level) []logrus.Level {
 			ls[i] = logrus.PanicLevel
 		case l == FatalLevel:
 			ls[i] = logrus.FatalLevel
+		case l == TraceLevel:
+			ls[i] = logrus.Level(TraceLevel)
 		}
 	}
 	return ls
@@ -339,183 +684,207 @@ func convert2logrusLevels(levels []Level) []logrus.Level {
 
 // AddSentryHook will add a sentry hook to baseLogger
 func AddSentryHook(dsn string, level Level) error {
-	return addSentryHook(baseLogger, dsn, level)
+	_, err := addSentryHook(baseLogger, dsn, level)
+	return err
 }
 
-func addSentryHook(l logger, dsn string, level Level) error {
+func addSentryHook(l logger, dsn string, level Level) (HookInfo, error) {
 	ls := convert2logrusLevels(higHerLevel(level))
-	hook, err := logrus_sentry.NewSentryHook(dsn, ls)
+	client, err := raven.New(dsn)
 	if err != nil {
-		return err
+		return HookInfo{}, err
 	}
-	l.entry.Logger.Hooks.Add(hook)
-	return nil
+	hook := newSentryHook(client, ls, false)
+	return l.core.addHook(&hookEntry{HookInfo: HookInfo{Kind: "sentry", Level: level}, hook: hook}).HookInfo, nil
 }
 
 // AddAsyncSentryHook will add a async sentry hook to base logger
 func AddAsyncSentryHook(dsn string, level Level) error {
-	return addAsyncSentryHook(baseLogger, dsn, level)
+	_, err := addAsyncSentryHook(baseLogger, dsn, level)
+	return err
 }
 
-func addAsyncSentryHook(l logger, dsn string, level Level) error {
+func addAsyncSentryHook(l logger, dsn string, level Level) (HookInfo, error) {
 	ls := convert2logrusLevels(higHerLevel(level))
-	hook, err := logrus_sentry.NewAsyncSentryHook(dsn, ls)
+	client, err := raven.New(dsn)
 	if err != nil {
-		return err
+		return HookInfo{}, err
 	}
-	l.entry.Logger.Hooks.Add(hook)
-	return nil
+	hook := newSentryHook(client, ls, true)
+	return l.core.addHook(&hookEntry{HookInfo: HookInfo{Kind: "sentry", Level: level}, hook: hook}).HookInfo, nil
 }
 
 // AddSentryHookWithTag wii add a sentry hook with tag to baseLogger
 func AddSentryHookWithTag(dsn string, tags map[string]string, level Level) error {
-	return addSentryHookWithTag(baseLogger, dsn, tags, level)
+	_, err := addSentryHookWithTag(baseLogger, dsn, tags, level)
+	return err
 }
 
-func addSentryHookWithTag(l logger, dsn string, tags map[string]string, level Level) error {
+func addSentryHookWithTag(l logger, dsn string, tags map[string]string, level Level) (HookInfo, error) {
 	ls := convert2logrusLevels(higHerLevel(level))
-	hook, err := logrus_sentry.NewWithTagsSentryHook(dsn, tags, ls)
+	client, err := raven.NewWithTags(dsn, tags)
 	if err != nil {
-		return err
+		return HookInfo{}, err
 	}
-	l.entry.Logger.Hooks.Add(hook)
-	return nil
+	hook := newSentryHook(client, ls, false)
+	return l.core.addHook(&hookEntry{HookInfo: HookInfo{Kind: "sentry", Level: level}, hook: hook}).HookInfo, nil
 }
 
 // AddRotateHook will add a rotate hook to baseLogger
 func AddRotateHook(path string, maxAge, rotateTime time.Duration, format string, level Level) error {
-	return addRotateHook(baseLogger, path, maxAge, rotateTime, format, dftFormatter, level)
+	_, err := addRotateHook(baseLogger, path, maxAge, rotateTime, format, dftFormatter, level)
+	return err
 }
 
 // AddRotateHookWithFormatter will add a rotate hook to baseLogger with formatter
 func AddRotateHookWithFormatter(path string, maxAge, rotateTime time.Duration, format string, formatter Formatter, level Level) error {
-	return addRotateHook(baseLogger, path, maxAge, rotateTime, format, formatter, level)
+	_, err := addRotateHook(baseLogger, path, maxAge, rotateTime, format, formatter, level)
+	return err
 }
 
-func addRotateHook(l logger, path string, maxAge, rotateTime time.Duration, format string, formatter Formatter, level Level) error {
+func addRotateHook(l logger, path string, maxAge, rotateTime time.Duration, format string, formatter Formatter, level Level) (HookInfo, error) {
 	if err := createDir(path); err != nil {
-		return err
+		return HookInfo{}, err
 	}
 
-	ls := convert2logrusLevels(higHerLevel(level))
 	writer, err := rotatelogs.New(
 		fmt.Sprintf("%s.%s", path, format), rotatelogs.WithLinkName(path),
 		rotatelogs.WithMaxAge(maxAge),
 		rotatelogs.WithRotationTime(rotateTime),
 	)
 	if err != nil {
-		return err
+		return HookInfo{}, err
 	}
 
-	writeMap := getWriteMap(ls, writer)
+	// the writer is set for every level so that the level of the hook
+	// can be changed later on, the registry decides which levels fire it
+	writeMap := getWriteMap(convert2logrusLevels(levelSlice), writer)
 
 	hook := lfshook.NewHook(writeMap)
 	hook.SetFormatter(formatter)
-	l.entry.Logger.Hooks.Add(hook)
-	return nil
+	return l.core.addHook(&hookEntry{HookInfo: HookInfo{Kind: "rotate", Target: path, Level: level}, hook: hook, writer: writer}).HookInfo, nil
 }
 
 // AddRotateHookByDay will add a rotate hook to baseLogger rotating by day
 func AddRotateHookByDay(path string, maxAge, rotateDay int, level Level) error {
-	return addRotateHookByDay(baseLogger, path, maxAge, rotateDay, dftFormatter, level)
+	_, err := addRotateHookByDay(baseLogger, path, maxAge, rotateDay, dftFormatter, level)
+	return err
 }
 
 // AddRotateHookByDayWithFormatter will add a rotate hook with formatter to baseLogger rotating by day
 func AddRotateHookByDayWithFormatter(path string, maxAge, rotateDay int, formatter Formatter, level Level) error {
-	return addRotateHookByDay(baseLogger, path, maxAge, rotateDay, formatter, level)
+	_, err := addRotateHookByDay(baseLogger, path, maxAge, rotateDay, formatter, level)
+	return err
 }
 
-func addRotateHookByDay(l logger, path string, maxAge, rotateDay int, formatter Formatter, level Level) error {
+func addRotateHookByDay(l logger, path string, maxAge, rotateDay int, formatter Formatter, level Level) (HookInfo, error) {
 	if err := createDir(path); err != nil {
-		return err
+		return HookInfo{}, err
 	}
 
-	ls := convert2logrusLevels(higHerLevel(level))
 	writer, err := rotatelogs.New(
 		fmt.Sprintf("%s.%s", path, "%Y-%m-%d"), rotatelogs.WithLinkName(path),
 		rotatelogs.WithMaxAge(time.Duration(maxAge)*time.Hour*24),
 		rotatelogs.WithRotationTime(time.Duration(rotateDay)*time.Hour*24),
 	)
 	if err != nil {
-		return err
+		return HookInfo{}, err
 	}
 
-	writeMap := getWriteMap(ls, writer)
+	// the writer is set for every level so that the level of the hook
+	// can be changed later on, the registry decides which levels fire it
+	writeMap := getWriteMap(convert2logrusLevels(levelSlice), writer)
 
 	hook := lfshook.NewHook(writeMap)
 	hook.SetFormatter(formatter)
-	l.entry.Logger.Hooks.Add(hook)
-
-	return nil
+	return l.core.addHook(&hookEntry{HookInfo: HookInfo{Kind: "rotate", Target: path, Level: level}, hook: hook, writer: writer}).HookInfo, nil
 }
 
 // AddRotateHookByHour will add a rotate hook to baseLogger rotating by hour
 func AddRotateHookByHour(path string, maxAge, rotateHour int, level Level) error {
-	return addRotateHookByHour(baseLogger, path, maxAge, rotateHour, dftFormatter, level)
+	_, err := addRotateHookByHour(baseLogger, path, maxAge, rotateHour, dftFormatter, level)
+	return err
 }
 
 // AddRotateHookByHourWithFormatter will add a rotate hook to baseLogger rotating by hour with formatter
 func AddRotateHookByHourWithFormatter(path string, maxAge, rotateHour int, formatter Formatter, level Level) error {
-	return addRotateHookByHour(baseLogger, path, maxAge, rotateHour, formatter, level)
+	_, err := addRotateHookByHour(baseLogger, path, maxAge, rotateHour, formatter, level)
+	return err
 }
 
 // AddRotateHookByHour will add a rotate hook to baseLogger rotating by hour
-func addRotateHookByHour(l logger, path string, maxAge, rotateHour int, formatter Formatter, level Level) error {
+func addRotateHookByHour(l logger, path string, maxAge, rotateHour int, formatter Formatter, level Level) (HookInfo, error) {
 	if err := createDir(path); err != nil {
-		return err
+		return HookInfo{}, err
 	}
 
-	ls := convert2logrusLevels(higHerLevel(level))
 	writer, err := rotatelogs.New(
 		fmt.Sprintf("%s.%s", path, "%Y-%m-%d@%H:00"), rotatelogs.WithLinkName(path),
 		rotatelogs.WithMaxAge(time.Duration(maxAge)*time.Hour),
 		rotatelogs.WithRotationTime(time.Duration(maxAge)*time.Hour),
 	)
 	if err != nil {
-		return err
+		return HookInfo{}, err
 	}
 
-	writeMap := getWriteMap(ls, writer)
+	// the writer is set for every level so that the level of the hook
+	// can be changed later on, the registry decides which levels fire it
+	writeMap := getWriteMap(convert2logrusLevels(levelSlice), writer)
 
 	hook := lfshook.NewHook(writeMap)
 	hook.SetFormatter(formatter)
-	l.entry.Logger.Hooks.Add(hook)
-
-	return nil
+	return l.core.addHook(&hookEntry{HookInfo: HookInfo{Kind: "rotate", Target: path, Level: level}, hook: hook, writer: writer}).HookInfo, nil
 }
 
 // AddGrayLogHook will add a sync graylog hook to base logger
 func AddGrayLogHook(ip string, port int, extra map[string]interface{}, level Level) error {
-	return addGrayLogHook(baseLogger, ip, port, extra, level)
+	_, err := addGrayLogHook(baseLogger, ip, port, extra, level)
+	return err
 }
 
-func addGrayLogHook(l logger, ip string, port int, extra map[string]interface{}, level Level) error {
+func addGrayLogHook(l logger, ip string, port int, extra map[string]interface{}, level Level) (HookInfo, error) {
 
 	lgLevels := convert2logrusLevels(higHerLevel(level))
 	hook := graylog.NewGraylogHook(fmt.Sprintf("%s:%d", ip, port), extra, lgLevels...)
-	l.grayHooks = append(l.grayHooks, hook)
-	l.entry.Logger.Hooks.Add(hook)
-	return nil
+	return l.core.addHook(&hookEntry{HookInfo: HookInfo{Kind: "graylog", Target: fmt.Sprintf("%s:%d", ip, port), Level: level}, hook: graylogHook{hook}}).HookInfo, nil
 }
 
 // AddAsyncGraylogHook will add a sync graylog hook to base logger and will send log by asyncing
 func AddAsyncGraylogHook(ip string, port int, extra map[string]interface{}, level Level) error {
-	return addAsyncGraylogHook(baseLogger, ip, port, extra, level)
+	_, err := addAsyncGraylogHook(baseLogger, ip, port, extra, level)
+	return err
 }
 
-func addAsyncGraylogHook(l logger, ip string, port int, extra map[string]interface{}, level Level) error {
+func addAsyncGraylogHook(l logger, ip string, port int, extra map[string]interface{}, level Level) (HookInfo, error) {
 	lgLevels := convert2logrusLevels(higHerLevel(level))
 	hook := graylog.NewAsyncGraylogHook(fmt.Sprintf("%s:%d", ip, port), extra, lgLevels...)
-	l.grayHooks = append(l.grayHooks, hook)
-	l.entry.Logger.Hooks.Add(hook)
-	return nil
+	return l.core.addHook(&hookEntry{HookInfo: HookInfo{Kind: "graylog", Target: fmt.Sprintf("%s:%d", ip, port), Level: level}, hook: graylogHook{hook}}).HookInfo, nil
+}
+
+// graylogHook sends Trace entries with the Debug level, GELF levels are
+// syslog severities and stop at debug.
+type graylogHook struct {
+	*graylog.GraylogHook
+}
+
+func (h graylogHook) Fire(entry *logrus.Entry) error {
+	if entry.Level > logrus.DebugLevel {
+		e := *entry
+		e.Level = logrus.DebugLevel
+		entry = &e
+	}
+	return h.GraylogHook.Fire(entry)
 }
 
 // GrayAsyncHookFlush flush all async gray hook
 func GrayAsyncHookFlush() {
-	for _, h := range baseLogger.grayHooks {
-		h.Flush()
-	}
+	baseLogger.GrayAsyncHookFlush()
+}
+
+// Reopen closes the files written by the rotate hooks of every logger,
+// they are opened again on the next write.
+func Reopen() error {
+	return rotateFiles.reopen()
 }
 
 // SetOutput set output writer of base logger object
@@ -552,137 +921,334 @@ func Base() Logger {
 }
 
 func (l logger) With(key string, value interface{}) Logger {
-	return logger{
-		entry: l.entry.WithField(key, value),
+	l.entry = l.entry.WithField(key, value)
+	return l
+}
+
+func (l logger) WithFields(fields map[string]interface{}) Logger {
+	l.entry = l.entry.WithFields(logrus.Fields(fields))
+	return l
+}
+
+func (l logger) WithError(err error) Logger {
+	if err == nil {
+		return l
+	}
+	l.entry = l.entry.WithFields(errorFields(err))
+	return l
+}
+
+type stackTracer interface {
+	StackTrace() errors.StackTrace
+}
+
+// errorFields returns the fields describing err: the error itself under
+// logrus.ErrorKey so hooks such as Sentry receive the original value, its
+// type, the messages of the wrapped errors and the pkg/errors stack if any.
+func errorFields(err error) logrus.Fields {
+	fields := logrus.Fields{
+		logrus.ErrorKey: err,
+		"error_type":    fmt.Sprintf("%T", err),
+	}
+
+	var chain []string
+	var stack errors.StackTrace
+	for e := err; e != nil; e = unwrap(e) {
+		// pkg/errors wraps a message and a stack in two layers with the same text
+		if msg := e.Error(); len(chain) == 0 || chain[len(chain)-1] != msg {
+			chain = append(chain, msg)
+		}
+		if st, ok := e.(stackTracer); ok {
+			// keep the innermost stack, it is the closest to the origin
+			stack = st.StackTrace()
+		}
+	}
+	if len(chain) > 1 {
+		fields["error_chain"] = chain
+	}
+	if stack != nil {
+		fields["error_stack"] = fmt.Sprintf("%+v", stack)
+	}
+	return fields
+}
+
+// unwrap returns the error wrapped by err, supporting both the standard
+// Unwrap method and the Cause method of pkg/errors.
+func unwrap(err error) error {
+	switch e := err.(type) {
+	case interface{ Unwrap() error }:
+		return e.Unwrap()
+	case interface{ Cause() error }:
+		return e.Cause()
+	}
+	return nil
+}
+
+// Trace logs a message at level Trace on the standard logger.
+func (l logger) Trace(args ...interface{}) {
+	if l.IsEnabled(TraceLevel) {
+		l.log(TraceLevel, fmt.Sprint(args...), nil)
+	}
+}
+
+// Traceln logs a message at level Trace on the standard logger.
+func (l logger) Traceln(args ...interface{}) {
+	if l.IsEnabled(TraceLevel) {
+		l.log(TraceLevel, sprintln(args...), nil)
+	}
+}
+
+// Tracef logs a message at level Trace on the standard logger.
+func (l logger) Tracef(format string, args ...interface{}) {
+	if l.IsEnabled(TraceLevel) {
+		l.logf(TraceLevel, format, args)
 	}
-	//return logger{entry: l.entry.WithField(key, value)}
 }
 
 // Debug logs a message at level Debug on the standard logger.
 func (l logger) Debug(args ...interface{}) {
-	l.sourced().Debug(args...)
+	if l.IsEnabled(DebugLevel) {
+		l.log(DebugLevel, fmt.Sprint(args...), nil)
+	}
 }
 
 // Debug logs a message at level Debug on the standard logger.
 func (l logger) Debugln(args ...interface{}) {
-	l.sourced().Debugln(args...)
+	if l.IsEnabled(DebugLevel) {
+		l.log(DebugLevel, sprintln(args...), nil)
+	}
 }
 
 // Debugf logs a message at level Debug on the standard logger.
 func (l logger) Debugf(format string, args ...interface{}) {
-	l.sourced().Debugf(format, args...)
+	if l.IsEnabled(DebugLevel) {
+		l.logf(DebugLevel, format, args)
+	}
 }
 
 // Info logs a message at level Info on the standard logger.
 func (l logger) Info(args ...interface{}) {
-	l.sourced().Info(args...)
+	if l.IsEnabled(InfoLevel) {
+		l.log(InfoLevel, fmt.Sprint(args...), nil)
+	}
 }
 
 // Info logs a message at level Info on the standard logger.
 func (l logger) Infoln(args ...interface{}) {
-	l.sourced().Infoln(args...)
+	if l.IsEnabled(InfoLevel) {
+		l.log(InfoLevel, sprintln(args...), nil)
+	}
 }
 
 // Infof logs a message at level Info on the standard logger.
 func (l logger) Infof(format string, args ...interface{}) {
-	l.sourced().Infof(format, args...)
+	if l.IsEnabled(InfoLevel) {
+		l.logf(InfoLevel, format, args)
+	}
 }
 
 // Warn logs a message at level Warn on the standard logger.
 func (l logger) Warn(args ...interface{}) {
-	l.sourced().Warn(args...)
+	if l.IsEnabled(WarnLevel) {
+		l.log(WarnLevel, fmt.Sprint(args...), nil)
+	}
 }
 
 // Warn logs a message at level Warn on the standard logger.
 func (l logger) Warnln(args ...interface{}) {
-	l.sourced().Warnln(args...)
+	if l.IsEnabled(WarnLevel) {
+		l.log(WarnLevel, sprintln(args...), nil)
+	}
 }
 
 // Warnf logs a message at level Warn on the standard logger.
 func (l logger) Warnf(format string, args ...interface{}) {
-	l.sourced().Warnf(format, args...)
+	if l.IsEnabled(WarnLevel) {
+		l.logf(WarnLevel, format, args)
+	}
 }
 
 // Error logs a message at level Error on the standard logger.
 func (l logger) Error(args ...interface{}) {
-	l.sourced().Error(args...)
+	if l.IsEnabled(ErrorLevel) {
+		l.log(ErrorLevel, fmt.Sprint(args...), nil)
+	}
 }
 
 // Error logs a message at level Error on the standard logger.
 func (l logger) Errorln(args ...interface{}) {
-	l.sourced().Errorln(args...)
+	if l.IsEnabled(ErrorLevel) {
+		l.log(ErrorLevel, sprintln(args...), nil)
+	}
 }
 
 // Errorf logs a message at level Error on the standard logger.
 func (l logger) Errorf(format string, args ...interface{}) {
-	l.sourced().Errorf(format, args...)
+	if l.IsEnabled(ErrorLevel) {
+		l.logf(ErrorLevel, format, args)
+	}
 }
 
 // Fatal logs a message at level Fatal on the standard logger.
 func (l logger) Fatal(args ...interface{}) {
-	l.sourced().Fatal(args...)
+	if l.IsEnabled(FatalLevel) {
+		l.log(FatalLevel, fmt.Sprint(args...), nil)
+	} else {
+		l.core.fatalExit()
+	}
 }
 
 // Fatal logs a message at level Fatal on the standard logger.
 func (l logger) Fatalln(args ...interface{}) {
-	l.sourced().Fatalln(args...)
+	if l.IsEnabled(FatalLevel) {
+		l.log(FatalLevel, sprintln(args...), nil)
+	} else {
+		l.core.fatalExit()
+	}
 }
 
 // Fatalf logs a message at level Fatal on the standard logger.
 func (l logger) Fatalf(format string, args ...interface{}) {
-	l.sourced().Fatalf(format, args...)
+	if l.IsEnabled(FatalLevel) {
+		l.logf(FatalLevel, format, args)
+	} else {
+		l.core.fatalExit()
+	}
+}
+
+// Panic logs a message at level Panic on the standard logger, then panics
+// with the original value if a single argument is given or with the message.
+func (l logger) Panic(args ...interface{}) {
+	msg := fmt.Sprint(args...)
+	if l.IsEnabled(PanicLevel) {
+		l.log(PanicLevel, msg, nil)
+	}
+	panic(panicValue(msg, args))
+}
+
+// Panicln logs a message at level Panic on the standard logger, then panics.
+func (l logger) Panicln(args ...interface{}) {
+	msg := sprintln(args...)
+	if l.IsEnabled(PanicLevel) {
+		l.log(PanicLevel, msg, nil)
+	}
+	panic(msg)
+}
+
+// Panicf logs a message at level Panic on the standard logger, then panics.
+func (l logger) Panicf(format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	if l.IsEnabled(PanicLevel) {
+		l.log(PanicLevel, msg, nil)
+	}
+	panic(msg)
+}
+
+// TraceFn logs the message returned by fn at level Trace. fn is only called
+// when the level is enabled.
+func (l logger) TraceFn(fn func() string) {
+	if l.IsEnabled(TraceLevel) {
+		l.log(TraceLevel, fn(), nil)
+	}
+}
+
+// DebugFn logs the message returned by fn at level Debug. fn is only called
+// when the level is enabled.
+func (l logger) DebugFn(fn func() string) {
+	if l.IsEnabled(DebugLevel) {
+		l.log(DebugLevel, fn(), nil)
+	}
+}
+
+// InfoFn logs the message returned by fn at level Info. fn is only called
+// when the level is enabled.
+func (l logger) InfoFn(fn func() string) {
+	if l.IsEnabled(InfoLevel) {
+		l.log(InfoLevel, fn(), nil)
+	}
+}
+
+// WarnFn logs the message returned by fn at level Warn. fn is only called
+// when the level is enabled.
+func (l logger) WarnFn(fn func() string) {
+	if l.IsEnabled(WarnLevel) {
+		l.log(WarnLevel, fn(), nil)
+	}
+}
+
+// ErrorFn logs the message returned by fn at level Error. fn is only called
+// when the level is enabled.
+func (l logger) ErrorFn(fn func() string) {
+	if l.IsEnabled(ErrorLevel) {
+		l.log(ErrorLevel, fn(), nil)
+	}
 }
 
 func (l logger) AddSentryHook(dsn string, level Level) error {
-	return addSentryHook(l, dsn, level)
+	_, err := addSentryHook(l, dsn, level)
+	return err
 }
 
 func (l logger) AddSentryHookWithTag(dsn string, tags map[string]string, level Level) error {
-	return addSentryHookWithTag(l, dsn, tags, level)
+	_, err := addSentryHookWithTag(l, dsn, tags, level)
+	return err
 }
 
 func (l logger) AddRotateHook(path string, maxAge, rotateTime time.Duration, format string, level Level) error {
-	return addRotateHook(baseLogger, path, maxAge, rotateTime, format, dftFormatter, level)
+	_, err := addRotateHook(l, path, maxAge, rotateTime, format, dftFormatter, level)
+	return err
 }
 
 func (l logger) AddRotateHookWithFormatter(path string, maxAge, rotateTime time.Duration, format string, formatter Formatter, level Level) error {
-	return addRotateHook(baseLogger, path, maxAge, rotateTime, format, formatter, level)
+	_, err := addRotateHook(l, path, maxAge, rotateTime, format, formatter, level)
+	return err
 }
 
 func (l logger) AddRotateHookByDay(path string, maxAge, rotateDay int, level Level) error {
-	return addRotateHookByDay(l, path, maxAge, rotateDay, dftFormatter, level)
+	_, err := addRotateHookByDay(l, path, maxAge, rotateDay, dftFormatter, level)
+	return err
 }
 
 func (l logger) AddRotateHookByDayWithFormatter(path string, maxAge, rotateDay int, formatter Formatter, level Level) error {
-	return addRotateHookByHour(l, path, maxAge, rotateDay, formatter, level)
+	_, err := addRotateHookByDay(l, path, maxAge, rotateDay, formatter, level)
+	return err
 }
 
 func (l logger) AddRotateHookByHour(path string, maxAge, rotateHour int, level Level) error {
-	return addRotateHookByHour(baseLogger, path, maxAge, rotateHour, dftFormatter, level)
+	_, err := addRotateHookByHour(l, path, maxAge, rotateHour, dftFormatter, level)
+	return err
 }
 
 func (l logger) AddRotateHookByHourWithFormatter(path string, maxAge, rotateHour int, formatter Formatter, level Level) error {
-	return addRotateHookByHour(l, path, maxAge, rotateHour, formatter, level)
+	_, err := addRotateHookByHour(l, path, maxAge, rotateHour, formatter, level)
+	return err
 }
 
 func (l logger) AddAsyncSentryHook(dsn string, level Level) error {
-	return addAsyncSentryHook(l, dsn, level)
+	_, err := addAsyncSentryHook(l, dsn, level)
+	return err
 }
 
 // AddGrayLogHook will add a rotate hook to baseLogger
 func (l logger) AddGrayLogHook(ip string, port int, extra map[string]interface{}, level Level) error {
-	return addGrayLogHook(l, ip, port, extra, level)
+	_, err := addGrayLogHook(l, ip, port, extra, level)
+	return err
 }
 
 // AddGrayLogHook will add a sync graylog hook to base logger
 func (l logger) AddAsyncGraylogHook(ip string, port int, extra map[string]interface{}, level Level) error {
-	return addAsyncGraylogHook(l, ip, port, extra, level)
+	_, err := addAsyncGraylogHook(l, ip, port, extra, level)
+	return err
 }
 
 // GrayAsyncHookFlush flush all async gray hook
 func (l logger) GrayAsyncHookFlush() {
-	for _, h := range l.grayHooks {
-		h.Flush()
+	l.core.hooksMu.RLock()
+	defer l.core.hooksMu.RUnlock()
+	for _, h := range l.core.hooks {
+		if gh, ok := h.hook.(graylogHook); ok {
+			gh.Flush()
+		}
 	}
 }
 
@@ -692,16 +1258,66 @@ func (l logger) SetOutput(w io.Writer) {
 
 // NewLogger returns a new Logger logging to out.
 func NewLogger(w io.Writer) Logger {
-	l := logrus.New()
-	l.Out = w
-	return logger{entry: logrus.NewEntry(l), grayHooks: make([]*graylog.GraylogHook, 0)}
+	l := newLogrusLogger(w)
+	return logger{entry: logrus.NewEntry(l), core: newCore(l)}
 }
 
 // NewNopLogger returns a logger that discards all log messages.
 func NewNopLogger() Logger {
-	l := logrus.New()
-	l.Out = ioutil.Discard
-	return logger{entry: logrus.NewEntry(l), grayHooks: make([]*graylog.GraylogHook, 0)}
+	l := newLogrusLogger(ioutil.Discard)
+	return logger{entry: logrus.NewEntry(l), core: newCore(l)}
+}
+
+// loggerOf returns the logger behind l. An other implementation of Logger
+// is wrapped in a logger forwarding every entry to it with WithFields and
+// Log, its level is checked when the entry is forwarded. The source field
+// is forwarded with the others, l may replace it with its own.
+func loggerOf(l Logger) logger {
+	if l, ok := l.(logger); ok {
+		return l
+	}
+	fl := NewLogger(NullOutput).(logger)
+	fl.core.setLevel(TraceLevel)
+	fl.core.addHook(&hookEntry{HookInfo: HookInfo{Kind: "forward", Level: TraceLevel}, hook: forwardHook{l}})
+	return fl
+}
+
+// forwardHook sends the entries to a Logger.
+type forwardHook struct {
+	l Logger
+}
+
+func (h forwardHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h forwardHook) Fire(entry *logrus.Entry) error {
+	if level := Level(entry.Level); h.l.IsEnabled(level) {
+		h.l.WithFields(entry.Data).Log(level, entry.Message)
+	}
+	return nil
+}
+
+// SetLevel sets the level of the standard logger. It is safe to call
+// while logging.
+func SetLevel(level Level) {
+	baseLogger.core.setLevel(level)
+}
+
+// SetLevel sets the level of the logger and all the loggers sharing its
+// output.
+func (l logger) SetLevel(level Level) {
+	l.core.setLevel(level)
+}
+
+// GetLevel returns the level of the standard logger.
+func GetLevel() Level {
+	return baseLogger.core.level()
+}
+
+// IsEnabled reports whether an entry at level would be logged by the standard logger.
+func IsEnabled(level Level) bool {
+	return baseLogger.IsEnabled(level)
 }
 
 // With adds a field to the logger.
@@ -709,92 +1325,232 @@ func With(key string, value interface{}) Logger {
 	return baseLogger.With(key, value)
 }
 
+// WithFields adds a map of fields to the logger.
+func WithFields(fields map[string]interface{}) Logger {
+	return baseLogger.WithFields(fields)
+}
+
+// WithError adds an error, its type and its wrapped chain to the logger.
+func WithError(err error) Logger {
+	return baseLogger.WithError(err)
+}
+
+// Trace logs a message at level Trace on the standard logger.
+func Trace(args ...interface{}) {
+	if baseLogger.IsEnabled(TraceLevel) {
+		baseLogger.log(TraceLevel, fmt.Sprint(args...), nil)
+	}
+}
+
+// Traceln logs a message at level Trace on the standard logger.
+func Traceln(args ...interface{}) {
+	if baseLogger.IsEnabled(TraceLevel) {
+		baseLogger.log(TraceLevel, sprintln(args...), nil)
+	}
+}
+
+// Tracef logs a message at level Trace on the standard logger.
+func Tracef(format string, args ...interface{}) {
+	if baseLogger.IsEnabled(TraceLevel) {
+		baseLogger.logf(TraceLevel, format, args)
+	}
+}
+
 // Debug logs a message at level Debug on the standard logger.
 func Debug(args ...interface{}) {
-	baseLogger.sourced().Debug(args...)
+	if baseLogger.IsEnabled(DebugLevel) {
+		baseLogger.log(DebugLevel, fmt.Sprint(args...), nil)
+	}
 }
 
 // Debugln logs a message at level Debug on the standard logger.
 func Debugln(args ...interface{}) {
-	baseLogger.sourced().Debugln(args...)
+	if baseLogger.IsEnabled(DebugLevel) {
+		baseLogger.log(DebugLevel, sprintln(args...), nil)
+	}
 }
 
 // Debugf logs a message at level Debug on the standard logger.
 func Debugf(format string, args ...interface{}) {
-	baseLogger.sourced().Debugf(format, args...)
+	if baseLogger.IsEnabled(DebugLevel) {
+		baseLogger.logf(DebugLevel, format, args)
+	}
 }
 
 // Info logs a message at level Info on the standard logger.
 func Info(args ...interface{}) {
-	baseLogger.sourced().Info(args...)
+	if baseLogger.IsEnabled(InfoLevel) {
+		baseLogger.log(InfoLevel, fmt.Sprint(args...), nil)
+	}
 }
 
 // Infoln logs a message at level Info on the standard logger.
 func Infoln(args ...interface{}) {
-	baseLogger.sourced().Infoln(args...)
+	if baseLogger.IsEnabled(InfoLevel) {
+		baseLogger.log(InfoLevel, sprintln(args...), nil)
+	}
 }
 
 // Infof logs a message at level Info on the standard logger.
 func Infof(format string, args ...interface{}) {
-	baseLogger.sourced().Infof(format, args...)
+	if baseLogger.IsEnabled(InfoLevel) {
+		baseLogger.logf(InfoLevel, format, args)
+	}
 }
 
 // Warn logs a message at level Warn on the standard logger.
 func Warn(args ...interface{}) {
-	baseLogger.sourced().Warn(args...)
+	if baseLogger.IsEnabled(WarnLevel) {
+		baseLogger.log(WarnLevel, fmt.Sprint(args...), nil)
+	}
 }
 
 // Warnln logs a message at level Warn on the standard logger.
 func Warnln(args ...interface{}) {
-	baseLogger.sourced().Warnln(args...)
+	if baseLogger.IsEnabled(WarnLevel) {
+		baseLogger.log(WarnLevel, sprintln(args...), nil)
+	}
 }
 
 // Warnf logs a message at level Warn on the standard logger.
 func Warnf(format string, args ...interface{}) {
-	baseLogger.sourced().Warnf(format, args...)
+	if baseLogger.IsEnabled(WarnLevel) {
+		baseLogger.logf(WarnLevel, format, args)
+	}
 }
 
 // Error logs a message at level Error on the standard logger.
 func Error(args ...interface{}) {
-	baseLogger.sourced().Error(args...)
+	if baseLogger.IsEnabled(ErrorLevel) {
+		baseLogger.log(ErrorLevel, fmt.Sprint(args...), nil)
+	}
 }
 
 // Errorln logs a message at level Error on the standard logger.
 func Errorln(args ...interface{}) {
-	baseLogger.sourced().Errorln(args...)
+	if baseLogger.IsEnabled(ErrorLevel) {
+		baseLogger.log(ErrorLevel, sprintln(args...), nil)
+	}
 }
 
 // Errorf logs a message at level Error on the standard logger.
 func Errorf(format string, args ...interface{}) {
-	baseLogger.sourced().Errorf(format, args...)
+	if baseLogger.IsEnabled(ErrorLevel) {
+		baseLogger.logf(ErrorLevel, format, args)
+	}
 }
 
 // Fatal logs a message at level Fatal on the standard logger.
 func Fatal(args ...interface{}) {
-	baseLogger.sourced().Fatal(args...)
+	if baseLogger.IsEnabled(FatalLevel) {
+		baseLogger.log(FatalLevel, fmt.Sprint(args...), nil)
+	} else {
+		baseLogger.core.fatalExit()
+	}
 }
 
 // Fatalln logs a message at level Fatal on the standard logger.
 func Fatalln(args ...interface{}) {
-	baseLogger.sourced().Fatalln(args...)
+	if baseLogger.IsEnabled(FatalLevel) {
+		baseLogger.log(FatalLevel, sprintln(args...), nil)
+	} else {
+		baseLogger.core.fatalExit()
+	}
 }
 
 // Fatalf logs a message at level Fatal on the standard logger.
 func Fatalf(format string, args ...interface{}) {
-	baseLogger.sourced().Fatalf(format, args...)
+	if baseLogger.IsEnabled(FatalLevel) {
+		baseLogger.logf(FatalLevel, format, args)
+	} else {
+		baseLogger.core.fatalExit()
+	}
 }
 
-type errorLogWriter struct{}
+// Panic logs a message at level Panic on the standard logger, then panics
+// with the original value if a single argument is given or with the message.
+func Panic(args ...interface{}) {
+	msg := fmt.Sprint(args...)
+	if baseLogger.IsEnabled(PanicLevel) {
+		baseLogger.log(PanicLevel, msg, nil)
+	}
+	panic(panicValue(msg, args))
+}
+
+// Panicln logs a message at level Panic on the standard logger, then panics.
+func Panicln(args ...interface{}) {
+	msg := sprintln(args...)
+	if baseLogger.IsEnabled(PanicLevel) {
+		baseLogger.log(PanicLevel, msg, nil)
+	}
+	panic(msg)
+}
+
+// Panicf logs a message at level Panic on the standard logger, then panics.
+func Panicf(format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	if baseLogger.IsEnabled(PanicLevel) {
+		baseLogger.log(PanicLevel, msg, nil)
+	}
+	panic(msg)
+}
+
+// TraceFn logs the message returned by fn at level Trace on the standard logger.
+// fn is only called when the level is enabled.
+func TraceFn(fn func() string) {
+	if baseLogger.IsEnabled(TraceLevel) {
+		baseLogger.log(TraceLevel, fn(), nil)
+	}
+}
+
+// DebugFn logs the message returned by fn at level Debug on the standard logger.
+// fn is only called when the level is enabled.
+func DebugFn(fn func() string) {
+	if baseLogger.IsEnabled(DebugLevel) {
+		baseLogger.log(DebugLevel, fn(), nil)
+	}
+}
+
+// InfoFn logs the message returned by fn at level Info on the standard logger.
+// fn is only called when the level is enabled.
+func InfoFn(fn func() string) {
+	if baseLogger.IsEnabled(InfoLevel) {
+		baseLogger.log(InfoLevel, fn(), nil)
+	}
+}
+
+// WarnFn logs the message returned by fn at level Warn on the standard logger.
+// fn is only called when the level is enabled.
+func WarnFn(fn func() string) {
+	if baseLogger.IsEnabled(WarnLevel) {
+		baseLogger.log(WarnLevel, fn(), nil)
+	}
+}
+
+// ErrorFn logs the message returned by fn at level Error on the standard logger.
+// fn is only called when the level is enabled.
+func ErrorFn(fn func() string) {
+	if baseLogger.IsEnabled(ErrorLevel) {
+		baseLogger.log(ErrorLevel, fn(), nil)
+	}
+}
+
+type errorLogWriter struct {
+	l logger
+}
 
-func (errorLogWriter) Write(b []byte) (int, error) {
-	baseLogger.sourced().Error(string(b))
+func (w *errorLogWriter) Write(b []byte) (int, error) {
+	l := w.l
+	l.skip += stdLogSkip()
+	l.log(ErrorLevel, strings.TrimSuffix(string(b), "\n"), nil)
 	return len(b), nil
 }
 
 // NewErrorLogger returns a log.Logger that is meant to be used
 // in the ErrorLog field of an http.Server to log HTTP server errors.
+// The source of the entries is the caller of the log.Logger.
 func NewErrorLogger() *log.Logger {
-	return log.New(&errorLogWriter{}, "", 0)
+	return log.New(&errorLogWriter{l: baseLogger}, "", 0)
 }
 
 func createDir(filePath string) error {
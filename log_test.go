@@ -1,14 +1,19 @@
 package log
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"os"
 	"testing"
 	"time"
 
 	"path"
+	"strings"
 
+	pkgerrors "github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 )
 
@@ -203,3 +208,171 @@ func Test_higHerLevel(t *testing.T) {
 
 // var testInfoLog = "a test log of info level"
 // var testErrorLog = "a test log of error level"
+
+func TestWithError(t *testing.T) {
+	base := errors.New("connection refused")
+	err := pkgerrors.Wrap(fmt.Errorf("dial: %w", base), "fetch")
+
+	l := NewLogger(ioutil.Discard).WithError(err).(logger)
+	data := l.entry.Data
+
+	if data[logrus.ErrorKey] != err {
+		t.Fatalf("data[error] = %v, want %v", data[logrus.ErrorKey], err)
+	}
+	if data["error_type"] != "*errors.withStack" {
+		t.Fatalf("data[error_type] = %v", data["error_type"])
+	}
+	chain, _ := data["error_chain"].([]string)
+	if len(chain) != 3 {
+		t.Fatalf("len(chain) = %d != 3: %v", len(chain), chain)
+	}
+	if chain[2] != "connection refused" {
+		t.Fatalf("chain[2] = %q", chain[2])
+	}
+	if _, ok := data["error_stack"].(string); !ok {
+		t.Fatal("error_stack is missing")
+	}
+
+	if NewLogger(ioutil.Discard).WithError(nil).(logger).entry.Data[logrus.ErrorKey] != nil {
+		t.Fatal("WithError(nil) must not add fields")
+	}
+}
+
+func TestWithFields(t *testing.T) {
+	l := NewLogger(ioutil.Discard).WithFields(map[string]interface{}{"a": 1, "b": "2"}).(logger)
+	if l.entry.Data["a"] != 1 || l.entry.Data["b"] != "2" {
+		t.Fatalf("unexpected data %v", l.entry.Data)
+	}
+}
+
+func TestLazyMessage(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLogger(&buf)
+	l.(logger).entry.Logger.SetLevel(logrus.InfoLevel)
+
+	if l.IsEnabled(DebugLevel) {
+		t.Fatal("debug level must be disabled")
+	}
+	if !l.IsEnabled(WarnLevel) {
+		t.Fatal("warn level must be enabled")
+	}
+
+	l.DebugFn(func() string {
+		t.Fatal("fn must not be called for a disabled level")
+		return ""
+	})
+	l.InfoFn(func() string { return "computed" })
+	if !strings.Contains(buf.String(), "msg=computed") {
+		t.Fatalf("unexpected output %q", buf.String())
+	}
+	if !strings.Contains(buf.String(), `source="log_test.go:`) {
+		t.Fatalf("unexpected source in %q", buf.String())
+	}
+}
+
+func BenchmarkDebugfDisabled(b *testing.B) {
+	l := newBenchLogger(logrus.InfoLevel)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.Debugf("value %d", 42)
+	}
+}
+
+func BenchmarkDebugFnDisabled(b *testing.B) {
+	l := newBenchLogger(logrus.InfoLevel)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.DebugFn(func() string { return fmt.Sprintf("value %d", 42) })
+	}
+}
+
+func BenchmarkInfofEnabled(b *testing.B) {
+	l := newBenchLogger(logrus.InfoLevel)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.Infof("value %d", 42)
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	for _, l := range []Level{TraceLevel, DebugLevel, InfoLevel, WarnLevel, ErrorLevel, FatalLevel, PanicLevel} {
+		got, err := ParseLevel(l.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != l {
+			t.Fatalf("%s != %s", got, l)
+		}
+	}
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Fatal("expected an error for an invalid level")
+	}
+
+	ls := higHerLevel(TraceLevel)
+	if len(ls) != 7 || ls[0] != TraceLevel {
+		t.Fatalf("unexpected levels %v", ls)
+	}
+}
+
+func TestTrace(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLogger(&buf)
+	l.(logger).entry.Logger.Formatter = PrefixedFormatter
+	l.(logger).entry.Logger.SetLevel(logrus.DebugLevel)
+
+	l.Trace("hidden")
+	if buf.Len() != 0 {
+		t.Fatalf("unexpected output %q", buf.String())
+	}
+
+	l.(logger).entry.Logger.SetLevel(logrus.Level(TraceLevel))
+	l.Tracef("shown %d", 1)
+	if !strings.Contains(buf.String(), "[trace][log_test.go:") || !strings.Contains(buf.String(), "shown 1") {
+		t.Fatalf("unexpected output %q", buf.String())
+	}
+}
+
+func TestTraceDefaultFormatters(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLogger(&buf)
+	l.(logger).core.setLevel(TraceLevel)
+
+	l.Trace("hello")
+	if out := buf.String(); !strings.Contains(out, "level=trace msg=hello") {
+		t.Fatalf("unexpected output %q", out)
+	}
+
+	buf.Reset()
+	l.(logger).entry.Logger.Formatter = traceFormatter{&logrus.JSONFormatter{}}
+	l.Trace("hello")
+	if out := buf.String(); !strings.Contains(out, `"level":"trace"`) {
+		t.Fatalf("unexpected output %q", out)
+	}
+}
+
+func TestPanic(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLogger(&buf)
+
+	errBoom := errors.New("boom")
+	func() {
+		defer func() {
+			if r := recover(); r != errBoom {
+				t.Fatalf("recovered %v, want %v", r, errBoom)
+			}
+		}()
+		l.Panic(errBoom)
+	}()
+	if !strings.Contains(buf.String(), "level=panic") || !strings.Contains(buf.String(), "msg=boom") {
+		t.Fatalf("unexpected output %q", buf.String())
+	}
+
+	func() {
+		defer func() {
+			if r := recover(); r != "value 1" {
+				t.Fatalf("recovered %v", r)
+			}
+		}()
+		l.Panicf("value %d", 1)
+	}()
+}
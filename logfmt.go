@@ -0,0 +1,276 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/sirupsen/logrus"
+)
+
+// LogfmtFormatter formats the entries in logfmt, with the keys time,
+// level, source and msg first and the other fields sorted by key:
+//
+//	time=2006-01-02T15:04:05.000Z07:00 level=info source=main.go:12 msg="server started" port=8080
+//
+// A value is quoted when it is empty or holds spaces, equal signs, quotes,
+// backslashes or non printable characters. In quoted values the quotes,
+// backslashes, \n, \r and \t are escaped with a backslash and the other
+// non printable characters are written as \uXXXX, so that every line can
+// be read back with ParseLogfmt. Invalid UTF-8 is replaced by U+FFFD. The
+// characters of the keys that would need quoting are replaced by '_', the
+// fields named time, level or msg are written as fields.time and so on.
+// When several fields end up with the same key, like "a b" and a_b, only
+// the one already named so is written, or else the first by name.
+//
+// It is selected with -log.format=logger:stderr?format=logfmt.
+type LogfmtFormatter struct {
+	// TimeLayout is the layout of the time, DefaultLogfmtTimeLayout if
+	// empty.
+	TimeLayout string
+}
+
+// DefaultLogfmtTimeLayout is the time layout of LogfmtFormatter when
+// TimeLayout is empty.
+const DefaultLogfmtTimeLayout = "2006-01-02T15:04:05.000Z07:00"
+
+// logfmtClashKeys are the fields renamed with the fields. prefix so that
+// they do not clash with the keys written first.
+var logfmtClashKeys = map[string]bool{logrus.FieldKeyTime: true, logrus.FieldKeyLevel: true, logrus.FieldKeyMsg: true}
+
+// Format implements logrus.Formatter.
+func (f *LogfmtFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	layout := f.TimeLayout
+	if layout == "" {
+		layout = DefaultLogfmtTimeLayout
+	}
+
+	var b bytes.Buffer
+	b.WriteString("time=")
+	appendLogfmtValue(&b, entry.Time.Format(layout))
+	b.WriteString(" level=")
+	appendLogfmtValue(&b, Level(entry.Level).String())
+	if source, ok := entry.Data["source"]; ok {
+		b.WriteString(" source=")
+		appendLogfmtValue(&b, logfmtString(source))
+	}
+	b.WriteString(" msg=")
+	appendLogfmtValue(&b, entry.Message)
+
+	// fields is the field written for each output key
+	fields := make(map[string]string, len(entry.Data))
+	for k := range entry.Data {
+		if k == "source" {
+			continue
+		}
+		key := logfmtKey(k)
+		if prev, ok := fields[key]; ok && (prev == key || k != key && prev < k) {
+			continue
+		}
+		fields[key] = k
+	}
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		b.WriteByte(' ')
+		b.WriteString(key)
+		b.WriteByte('=')
+		appendLogfmtValue(&b, logfmtString(entry.Data[fields[key]]))
+	}
+	b.WriteByte('\n')
+	return b.Bytes(), nil
+}
+
+// logfmtString returns the text of a field value.
+func logfmtString(v interface{}) string {
+	switch v := v.(type) {
+	case Field:
+		var b bytes.Buffer
+		v.appendText(&b)
+		return b.String()
+	case error:
+		return v.Error()
+	}
+	return pString(v)
+}
+
+// logfmtBare reports whether r can appear in a value that is not quoted.
+func logfmtBare(r rune) bool {
+	return r > ' ' && r != '=' && r != '"' && r != '\\' && r != utf8.RuneError && unicode.IsPrint(r)
+}
+
+// logfmtKey returns the key written for the field k.
+func logfmtKey(k string) string {
+	if k == "" {
+		return "_"
+	}
+	var b strings.Builder
+	if logfmtClashKeys[k] {
+		b.WriteString("fields.")
+	}
+	for _, r := range k {
+		if !logfmtBare(r) {
+			r = '_'
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func appendLogfmtValue(b *bytes.Buffer, s string) {
+	quote := s == ""
+	for _, r := range s {
+		if !logfmtBare(r) {
+			quote = true
+			break
+		}
+	}
+	if !quote {
+		b.WriteString(s)
+		return
+	}
+
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"', '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if r == ' ' || unicode.IsPrint(r) {
+				b.WriteRune(r)
+			} else if r > 0xFFFF {
+				fmt.Fprintf(b, `\U%08x`, r)
+			} else {
+				fmt.Fprintf(b, `\u%04x`, r)
+			}
+		}
+	}
+	b.WriteByte('"')
+}
+
+// LogfmtPair is a key and its value read by ParseLogfmt.
+type LogfmtPair struct {
+	Key   string
+	Value string
+}
+
+// ParseLogfmt parses a line written by LogfmtFormatter, or any logfmt
+// line, into its pairs in order. A key without value has an empty value.
+func ParseLogfmt(line string) ([]LogfmtPair, error) {
+	var pairs []LogfmtPair
+	for i := 0; ; {
+		for i < len(line) && (line[i] == ' ' || line[i] == '\t' || line[i] == '\n' || line[i] == '\r') {
+			i++
+		}
+		if i == len(line) {
+			return pairs, nil
+		}
+
+		start := i
+		for i < len(line) && line[i] > ' ' && line[i] != '=' && line[i] != '"' {
+			i++
+		}
+		if i == start {
+			return nil, fmt.Errorf("logfmt: unexpected %q at offset %d", line[i], i)
+		}
+		p := LogfmtPair{Key: line[start:i]}
+		if i < len(line) && line[i] == '=' {
+			i++
+			if i < len(line) && line[i] == '"' {
+				value, n, err := unquoteLogfmt(line[i:])
+				if err != nil {
+					return nil, fmt.Errorf("logfmt: %v in value of %s at offset %d", err, p.Key, i)
+				}
+				p.Value = value
+				i += n
+			} else {
+				start = i
+				for i < len(line) && line[i] > ' ' && line[i] != '"' {
+					i++
+				}
+				p.Value = line[start:i]
+			}
+		}
+		if i < len(line) && line[i] > ' ' {
+			return nil, fmt.Errorf("logfmt: unexpected %q at offset %d", line[i], i)
+		}
+		pairs = append(pairs, p)
+	}
+}
+
+// unquoteLogfmt reads the quoted string at the start of s, it returns its
+// value and the number of bytes read.
+func unquoteLogfmt(s string) (string, int, error) {
+	var b bytes.Buffer
+	for i := 1; i < len(s); {
+		switch c := s[i]; c {
+		case '"':
+			return b.String(), i + 1, nil
+		case '\\':
+			if i+1 == len(s) {
+				return "", 0, fmt.Errorf("unterminated string")
+			}
+			switch e := s[i+1]; e {
+			case '"', '\\', '/':
+				b.WriteByte(e)
+				i += 2
+			case 'n':
+				b.WriteByte('\n')
+				i += 2
+			case 'r':
+				b.WriteByte('\r')
+				i += 2
+			case 't':
+				b.WriteByte('\t')
+				i += 2
+			case 'u', 'U':
+				n := 4
+				if e == 'U' {
+					n = 8
+				}
+				if i+2+n > len(s) {
+					return "", 0, fmt.Errorf("invalid escape")
+				}
+				r, err := strconv.ParseUint(s[i+2:i+2+n], 16, 32)
+				if err != nil {
+					return "", 0, fmt.Errorf("invalid escape")
+				}
+				b.WriteRune(rune(r))
+				i += 2 + n
+			default:
+				return "", 0, fmt.Errorf("invalid escape \\%c", e)
+			}
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated string")
+}
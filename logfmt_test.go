@@ -0,0 +1,146 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestLogfmtFormatter(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLogger(&buf)
+	l.(logger).entry.Logger.Formatter = &LogfmtFormatter{}
+
+	source := nextLine()
+	l.With("user", "bob").WithError(errors.New("not found")).Log(WarnLevel, "lookup failed", Int("status", 404), String("query", "a=b"), String("empty", ""))
+	out := buf.String()
+	fields := ` msg="lookup failed" empty="" error="not found" error_type=*errors.errorString query="a=b" status=404 user=bob` + "\n"
+	if !strings.Contains(out, ` level=warn source=`+source+fields) || !strings.HasPrefix(out, "time=") {
+		t.Fatalf("unexpected output %q", out)
+	}
+	if _, err := time.Parse(DefaultLogfmtTimeLayout, out[len("time="):strings.IndexByte(out, ' ')]); err != nil {
+		t.Fatalf("unexpected time in %q: %v", out, err)
+	}
+}
+
+func TestLogfmtRoundTrip(t *testing.T) {
+	values := []string{
+		"plain",
+		"",
+		"two words",
+		`say "hi"`,
+		`C:\path`,
+		"line\nbreak\r\tand tab",
+		"nul\x00bell\x07del\x7f",
+		"sep\u2028arator",
+		"日本語 ok",
+		"emoji \U0001F600",
+	}
+	data := logrus.Fields{"source": "main.go:1", "time": "clash", "bad key=": "v"}
+	for i, v := range values {
+		data[string(rune('a'+i))] = v
+	}
+	entry := &logrus.Entry{Data: data, Time: time.Now(), Level: logrus.InfoLevel, Message: "multi\nline"}
+	b, err := (&LogfmtFormatter{}).Format(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Count(b, []byte("\n")) != 1 {
+		t.Fatalf("entry on several lines: %q", b)
+	}
+
+	pairs, err := ParseLogfmt(string(b))
+	if err != nil {
+		t.Fatalf("parsing %q: %v", b, err)
+	}
+	got := make(map[string]string, len(pairs))
+	var keys []string
+	for _, p := range pairs {
+		got[p.Key] = p.Value
+		keys = append(keys, p.Key)
+	}
+	if strings.Join(keys[:4], ",") != "time,level,source,msg" || got["msg"] != "multi\nline" {
+		t.Fatalf("unexpected pairs %q", pairs)
+	}
+	for i, v := range values {
+		if k := string(rune('a' + i)); got[k] != v {
+			t.Errorf("%s = %q, want %q in %q", k, got[k], v, b)
+		}
+	}
+	if got["fields.time"] != "clash" || got["bad_key_"] != "v" {
+		t.Fatalf("unexpected pairs %q", pairs)
+	}
+}
+
+func TestLogfmtDuplicateKeys(t *testing.T) {
+	entry := &logrus.Entry{
+		Data:    logrus.Fields{"a b": 1, "a_b": 2, "a=b": 3, "time": "renamed", "fields.time": "real", "b c": 4, "b=c": 5, "z": 6},
+		Time:    time.Now(),
+		Level:   logrus.InfoLevel,
+		Message: "dup",
+	}
+	b, err := (&LogfmtFormatter{}).Format(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(b)
+	if want := ` msg=dup a_b=2 b_c=4 fields.time=real z=6` + "\n"; !strings.HasSuffix(out, want) {
+		t.Fatalf("got %q, want suffix %q", out, want)
+	}
+}
+
+func TestLogfmtFormatFlag(t *testing.T) {
+	formatter := origLogger.Formatter
+	defer func() {
+		origLogger.Formatter = formatter
+	}()
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	AddFlags(fs)
+	if err := fs.Parse([]string{"-log.format=logger:stderr?format=logfmt"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := origLogger.Formatter.(*LogfmtFormatter); !ok {
+		t.Fatalf("unexpected formatter %T", origLogger.Formatter)
+	}
+}
+
+func TestParseLogfmt(t *testing.T) {
+	pairs, err := ParseLogfmt(`a=1 flag b="x \"y\" \u00e9" c= d=\ok`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []LogfmtPair{{"a", "1"}, {"flag", ""}, {"b", `x "y" é`}, {"c", ""}, {"d", `\ok`}}
+	if len(pairs) != len(want) {
+		t.Fatalf("unexpected pairs %q", pairs)
+	}
+	for i := range want {
+		if pairs[i] != want[i] {
+			t.Fatalf("pair %d = %q, want %q", i, pairs[i], want[i])
+		}
+	}
+
+	for _, line := range []string{`a="open`, `a="bad \q"`, `=1`, `a=1"`, `a="x"b`, `a="\u12"`} {
+		if _, err := ParseLogfmt(line); err == nil {
+			t.Errorf("no error parsing %q", line)
+		}
+	}
+}
@@ -0,0 +1,240 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package logtest provides loggers and assertions for the tests of code
+// logging with github.com/lwhile/log.
+//
+// New returns a Logger recording its entries in memory:
+//
+//	l, rec := logtest.New()
+//	s := server.New(l)
+//	s.Handle(req)
+//	rec.AssertLogged(t, log.WarnLevel, "retrying", map[string]interface{}{"attempt": 2})
+//	rec.NoErrorsLogged(t)
+//
+// NewT returns a Logger writing to the test log instead.
+package logtest
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lwhile/log"
+	"github.com/sirupsen/logrus"
+)
+
+// Entry is an entry recorded by a Recorder.
+type Entry struct {
+	Time    time.Time
+	Level   log.Level
+	Message string
+	// Source is the source field of the entry, empty if it has none.
+	Source string
+	// Fields holds the other fields, with the typed fields converted to
+	// their plain value.
+	Fields map[string]interface{}
+}
+
+// String formats the entry for failure messages.
+func (e Entry) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[%s] %q", e.Level, e.Message)
+	if e.Source != "" {
+		fmt.Fprintf(&b, " source=%s", e.Source)
+	}
+	for _, k := range sortedKeys(e.Fields) {
+		fmt.Fprintf(&b, " %s=%v", k, e.Fields[k])
+	}
+	return b.String()
+}
+
+// Recorder records the entries of a Logger returned by New.
+type Recorder struct {
+	mu       sync.Mutex
+	entries  []Entry
+	exitCode int
+	exited   bool
+}
+
+// New returns a Logger at level Trace recording its entries in the
+// returned Recorder and writing nothing. Fatal does not exit the program,
+// the exit code is recorded and the Fatal call returns.
+func New() (log.Logger, *Recorder) {
+	r := &Recorder{}
+	l := log.NewLogger(log.NullOutput)
+	l.SetLevel(log.TraceLevel)
+	l.SetExitFunc(r.exit)
+	if _, err := l.AddHook(r, log.TraceLevel); err != nil {
+		panic(err)
+	}
+	return l, r
+}
+
+// Levels implements logrus.Hook.
+func (r *Recorder) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire implements logrus.Hook.
+func (r *Recorder) Fire(entry *logrus.Entry) error {
+	e := Entry{
+		Time:    entry.Time,
+		Level:   log.Level(entry.Level),
+		Message: entry.Message,
+		Fields:  make(map[string]interface{}, len(entry.Data)),
+	}
+	for k, v := range entry.Data {
+		if f, ok := v.(log.Field); ok {
+			v = f.Value()
+		}
+		if k == "source" {
+			e.Source = fmt.Sprint(v)
+			continue
+		}
+		e.Fields[k] = v
+	}
+
+	r.mu.Lock()
+	r.entries = append(r.entries, e)
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *Recorder) exit(code int) {
+	r.mu.Lock()
+	r.exitCode, r.exited = code, true
+	r.mu.Unlock()
+}
+
+// Entries returns the entries recorded so far.
+func (r *Recorder) Entries() []Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Entry(nil), r.entries...)
+}
+
+// Reset forgets the recorded entries and exit code.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	r.entries, r.exitCode, r.exited = nil, 0, false
+	r.mu.Unlock()
+}
+
+// Exited returns the exit code of the last Fatal entry and whether there
+// was one.
+func (r *Recorder) Exited() (code int, ok bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.exitCode, r.exited
+}
+
+// Find returns the first entry at level whose message contains msg and
+// which has the given fields. Field values are equal if they are deeply
+// equal or print the same, so that 2 matches a field set with log.Int64.
+func (r *Recorder) Find(level log.Level, msg string, fields map[string]interface{}) (Entry, bool) {
+	for _, e := range r.Entries() {
+		if e.Level == level && strings.Contains(e.Message, msg) && hasFields(e, fields) {
+			return e, true
+		}
+	}
+	return Entry{}, false
+}
+
+func hasFields(e Entry, fields map[string]interface{}) bool {
+	for k, want := range fields {
+		v, ok := e.Fields[k]
+		if k == "source" {
+			v, ok = e.Source, e.Source != ""
+		}
+		if !ok || !(reflect.DeepEqual(v, want) || fmt.Sprint(v) == fmt.Sprint(want)) {
+			return false
+		}
+	}
+	return true
+}
+
+// AssertLogged fails the test unless an entry matching level, msg and
+// fields was recorded, see Find.
+func (r *Recorder) AssertLogged(t testing.TB, level log.Level, msg string, fields map[string]interface{}) bool {
+	t.Helper()
+	if _, ok := r.Find(level, msg, fields); ok {
+		return true
+	}
+	t.Errorf("no [%s] entry containing %q with fields %v, recorded:%s", level, msg, fields, r.dump())
+	return false
+}
+
+// NoErrorsLogged fails the test if an entry at level Error or above was
+// recorded.
+func (r *Recorder) NoErrorsLogged(t testing.TB) bool {
+	t.Helper()
+	var errs []string
+	for _, e := range r.Entries() {
+		if e.Level <= log.ErrorLevel {
+			errs = append(errs, e.String())
+		}
+	}
+	if len(errs) == 0 {
+		return true
+	}
+	t.Errorf("unexpected errors logged:\n\t%s", strings.Join(errs, "\n\t"))
+	return false
+}
+
+func (r *Recorder) dump() string {
+	entries := r.Entries()
+	if len(entries) == 0 {
+		return " none"
+	}
+	var b strings.Builder
+	for _, e := range entries {
+		b.WriteString("\n\t")
+		b.WriteString(e.String())
+	}
+	return b.String()
+}
+
+// NewT returns a Logger at level Trace writing its entries to the log of
+// t, they are only shown when the test fails or with go test -v. Fatal
+// fails the test and stops its goroutine instead of exiting the program.
+func NewT(t testing.TB) log.Logger {
+	l := log.NewLogger(tWriter{t})
+	l.SetLevel(log.TraceLevel)
+	l.SetExitFunc(func(code int) {
+		t.Fatalf("log.Fatal called, exit code %d", code)
+	})
+	return l
+}
+
+type tWriter struct {
+	t testing.TB
+}
+
+func (w tWriter) Write(b []byte) (int, error) {
+	w.t.Log(strings.TrimSuffix(string(b), "\n"))
+	return len(b), nil
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,97 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logtest
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/lwhile/log"
+)
+
+// fakeT records the failures reported through it.
+type fakeT struct {
+	testing.TB
+	errs []string
+}
+
+func (t *fakeT) Helper() {}
+
+func (t *fakeT) Errorf(format string, args ...interface{}) {
+	t.errs = append(t.errs, fmt.Sprintf(format, args...))
+}
+
+func TestRecorder(t *testing.T) {
+	l, rec := New()
+	_, _, line, _ := runtime.Caller(0)
+	l.With("user", "alice").Tracew("retrying", "attempt", 2)
+	l.Log(log.InfoLevel, "served", log.Int("status", 200), log.Duration("took", 0))
+
+	entries := rec.Entries()
+	if len(entries) != 2 {
+		t.Fatalf("unexpected entries %v", entries)
+	}
+	if e := entries[0]; e.Level != log.TraceLevel || e.Message != "retrying" || e.Source != "logtest_test.go:"+strconv.Itoa(line+1) || e.Fields["user"] != "alice" {
+		t.Fatalf("unexpected entry %v", e)
+	}
+	if v := entries[1].Fields["status"]; v != int64(200) {
+		t.Fatalf("typed field recorded as %#v", v)
+	}
+
+	rec.AssertLogged(t, log.TraceLevel, "retry", map[string]interface{}{"attempt": 2, "user": "alice"})
+	rec.AssertLogged(t, log.InfoLevel, "", map[string]interface{}{"status": 200, "source": "logtest_test.go:" + strconv.Itoa(line+2)})
+	rec.NoErrorsLogged(t)
+
+	ft := &fakeT{TB: t}
+	rec.AssertLogged(ft, log.InfoLevel, "retrying", nil)
+	rec.AssertLogged(ft, log.TraceLevel, "retrying", map[string]interface{}{"attempt": 3})
+	if len(ft.errs) != 2 || !strings.Contains(ft.errs[0], `[info] "served"`) {
+		t.Fatalf("unexpected failures %q", ft.errs)
+	}
+
+	rec.Reset()
+	if len(rec.Entries()) != 0 {
+		t.Fatal("entries not reset")
+	}
+}
+
+func TestNoErrorsLogged(t *testing.T) {
+	l, rec := New()
+	l.WithError(errors.New("timeout")).Error("query failed")
+
+	ft := &fakeT{TB: t}
+	if rec.NoErrorsLogged(ft) || len(ft.errs) != 1 || !strings.Contains(ft.errs[0], "query failed") {
+		t.Fatalf("unexpected failures %q", ft.errs)
+	}
+	rec.AssertLogged(t, log.ErrorLevel, "query", map[string]interface{}{"error": "timeout"})
+}
+
+func TestFatal(t *testing.T) {
+	l, rec := New()
+	l.Fatal("cannot start")
+
+	if code, ok := rec.Exited(); !ok || code != 1 {
+		t.Fatalf("unexpected exit %d, %v", code, ok)
+	}
+	rec.AssertLogged(t, log.FatalLevel, "cannot start", nil)
+}
+
+func TestNewT(t *testing.T) {
+	l := NewT(t)
+	l.Debugw("visible with go test -v", "key", "value")
+}
@@ -0,0 +1,122 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// NameKey is the field under which the name of a named logger is logged.
+const NameKey = "logger"
+
+var namedLevels = struct {
+	sync.RWMutex
+	levels map[string]Level
+	// generation is bumped on every change so that named loggers know
+	// when their cached level is stale
+	generation uint64
+}{levels: make(map[string]Level)}
+
+// SetNamedLevel sets the level of the loggers named name and of all their
+// descendants without a level of their own. For example with "storage" set
+// to warn and "storage.raft" set to debug, a logger named "storage.wal" logs
+// at warn and one named "storage.raft.log" at debug. It takes effect on
+// existing loggers immediately.
+func SetNamedLevel(name string, level Level) {
+	namedLevels.Lock()
+	namedLevels.levels[name] = level
+	atomic.AddUint64(&namedLevels.generation, 1)
+	namedLevels.Unlock()
+}
+
+// UnsetNamedLevel removes the level set for name, its loggers then use the
+// level of their closest configured parent or the global level.
+func UnsetNamedLevel(name string) {
+	namedLevels.Lock()
+	delete(namedLevels.levels, name)
+	atomic.AddUint64(&namedLevels.generation, 1)
+	namedLevels.Unlock()
+}
+
+// NamedLevels returns a copy of the levels set with SetNamedLevel.
+func NamedLevels() map[string]Level {
+	namedLevels.RLock()
+	defer namedLevels.RUnlock()
+	levels := make(map[string]Level, len(namedLevels.levels))
+	for k, v := range namedLevels.levels {
+		levels[k] = v
+	}
+	return levels
+}
+
+// resolveNamedLevel returns the level set for name or its closest parent.
+func resolveNamedLevel(name string) (Level, bool) {
+	namedLevels.RLock()
+	defer namedLevels.RUnlock()
+	for {
+		if level, ok := namedLevels.levels[name]; ok {
+			return level, true
+		}
+		dot := strings.LastIndexByte(name, '.')
+		if dot < 0 {
+			return 0, false
+		}
+		name = name[:dot]
+	}
+}
+
+// loggerName is shared by a named logger and the loggers derived from it.
+// It caches the resolved level until the named levels change.
+type loggerName struct {
+	name string
+	// state packs the generation the level was resolved at plus one
+	// (upper bits, zero means never resolved), whether a level is set
+	// (bit 8) and the level (lower 8 bits)
+	state uint64
+}
+
+const namedLevelSet = 1 << 8
+
+func (n *loggerName) level() (Level, bool) {
+	gen := atomic.LoadUint64(&namedLevels.generation)
+	state := atomic.LoadUint64(&n.state)
+	if state>>9 != gen+1 {
+		level, ok := resolveNamedLevel(n.name)
+		state = (gen+1)<<9 | uint64(level)
+		if ok {
+			state |= namedLevelSet
+		}
+		atomic.StoreUint64(&n.state, state)
+	}
+	return Level(state & 0xff), state&namedLevelSet != 0
+}
+
+// Named returns a logger named name, or a child of the current name
+// separated by a dot. The name is logged under NameKey and its level can
+// be set with SetNamedLevel.
+func (l logger) Named(name string) Logger {
+	if l.name != nil {
+		name = l.name.name + "." + name
+	}
+	l.name = &loggerName{name: name}
+	l.entry = l.entry.WithField(NameKey, name)
+	return l
+}
+
+// Named returns a logger named name derived from the standard logger.
+func Named(name string) Logger {
+	return baseLogger.Named(name)
+}
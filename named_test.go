@@ -0,0 +1,83 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestNamedLevels(t *testing.T) {
+	defer UnsetNamedLevel("teststorage")
+	defer UnsetNamedLevel("teststorage.raft")
+
+	var buf bytes.Buffer
+	root := NewLogger(&buf)
+	root.(logger).entry.Logger.SetLevel(logrus.InfoLevel)
+
+	storage := root.Named("teststorage")
+	raft := storage.Named("raft")
+	wal := storage.Named("wal")
+
+	if raft.IsEnabled(DebugLevel) {
+		t.Fatal("debug must follow the global level before any named level is set")
+	}
+
+	SetNamedLevel("teststorage", WarnLevel)
+	SetNamedLevel("teststorage.raft", DebugLevel)
+
+	testCases := []struct {
+		l       Logger
+		level   Level
+		enabled bool
+	}{
+		{storage, InfoLevel, false},
+		{storage, WarnLevel, true},
+		{wal, InfoLevel, false},
+		{raft, DebugLevel, true},
+		{raft, TraceLevel, false},
+		{raft.Named("log"), DebugLevel, true},
+		{root, InfoLevel, true},
+		{root, DebugLevel, false},
+	}
+	for i, tc := range testCases {
+		if got := tc.l.IsEnabled(tc.level); got != tc.enabled {
+			t.Errorf("%d: IsEnabled(%s) = %v, want %v", i, tc.level, got, tc.enabled)
+		}
+	}
+
+	raft.Debug("elected")
+	if !strings.Contains(buf.String(), "logger=teststorage.raft") || !strings.Contains(buf.String(), "msg=elected") {
+		t.Fatalf("unexpected output %q", buf.String())
+	}
+
+	UnsetNamedLevel("teststorage.raft")
+	if raft.IsEnabled(DebugLevel) || raft.IsEnabled(InfoLevel) {
+		t.Fatal("raft must fall back to the storage level")
+	}
+}
+
+func TestNamedPFormatter(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLogger(&buf)
+	l.(logger).entry.Logger.Formatter = PrefixedFormatter
+
+	l.Named("api").Info("started")
+	if !strings.Contains(buf.String(), "[info][api][named_test.go:") {
+		t.Fatalf("unexpected output %q", buf.String())
+	}
+}
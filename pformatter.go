@@ -0,0 +1,247 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultPLayout is the layout of PFormatter when Layout is empty.
+const DefaultPLayout = "{time} [{level}][{name}][{source}] {msg} {fields}"
+
+// DefaultPTimeLayout is the time layout of PFormatter when TimeLayout is
+// empty. It has no fractional seconds like the lines written by earlier
+// versions, set TimeLayout to "2006-01-02 15:04:05.000" for milliseconds.
+const DefaultPTimeLayout = "2006-01-02 15:04:05"
+
+// PFormatter formats the entries on one line made of the tokens of Layout:
+//
+//	{time}    the time formatted with TimeLayout in Location
+//	{level}   the level
+//	{name}    the name of a named logger
+//	{source}  the source and the function name with SourceFunc
+//	{msg}     the message
+//	{fields}  the other fields as key=value
+//
+// A token with nothing to write is removed with the brackets around it,
+// or with the space before it. Unknown tokens are written as is. The
+// stack trace of the entry, if any, is written below the line.
+//
+// The zero value writes entries like:
+//
+//	2006-01-02 15:04:05 [info][api][server.go:42] started port=8080
+//
+// The entries of an unnamed logger without fields keep the
+// time [level][source] message format of earlier versions.
+type PFormatter struct {
+	// Layout is the layout of the line, DefaultPLayout if empty.
+	Layout string
+	// TimeLayout is the layout of {time}, DefaultPTimeLayout if empty.
+	TimeLayout string
+	// Location is the time zone of {time}, the zone of the entry time if
+	// nil.
+	Location *time.Location
+
+	// FieldOrder lists the fields written first by {fields}, in this
+	// order. The others follow sorted by key.
+	FieldOrder []string
+	// IncludeFields restricts {fields} to the listed fields when not
+	// empty.
+	IncludeFields []string
+	// ExcludeFields are never written by {fields}.
+	ExcludeFields []string
+}
+
+// PrefixedFormatter is a default variable that exported to package user
+var PrefixedFormatter = &PFormatter{}
+
+// pTokenKeys are the fields written by the other tokens than {fields}.
+var pTokenKeys = map[string]bool{NameKey: true, "source": true, FuncKey: true, StacktraceKey: true}
+
+// Format implements logrus.Formatter.
+func (p *PFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	layout := p.Layout
+	if layout == "" {
+		layout = DefaultPLayout
+	}
+
+	var b bytes.Buffer
+	for layout != "" {
+		open := strings.IndexByte(layout, '{')
+		end := strings.IndexByte(layout[open+1:], '}')
+		if open < 0 || end < 0 {
+			b.WriteString(layout)
+			break
+		}
+		end += open + 1
+		b.WriteString(layout[:open])
+		token, rest := layout[open+1:end], layout[end+1:]
+		layout = rest
+
+		n := b.Len()
+		if !p.appendToken(&b, token, entry) {
+			b.WriteString("{" + token + "}")
+			continue
+		}
+		if b.Len() > n {
+			continue
+		}
+		// nothing written, remove the brackets or the space around
+		if bytes.HasSuffix(b.Bytes(), []byte("[")) && strings.HasPrefix(layout, "]") {
+			b.Truncate(n - 1)
+			layout = layout[1:]
+		} else if bytes.HasSuffix(b.Bytes(), []byte(" ")) {
+			b.Truncate(n - 1)
+		}
+	}
+
+	// write the stack trace below the line
+	if st, ok := entry.Data[StacktraceKey].(Stacktrace); ok {
+		b.WriteByte('\n')
+		st.appendText(&b)
+	}
+	b.WriteByte('\n')
+	return b.Bytes(), nil
+}
+
+// appendToken writes the value of token, it returns false if the token is
+// unknown.
+func (p *PFormatter) appendToken(b *bytes.Buffer, token string, entry *logrus.Entry) bool {
+	switch token {
+	case "time":
+		layout := p.TimeLayout
+		if layout == "" {
+			layout = DefaultPTimeLayout
+		}
+		t := entry.Time
+		if p.Location != nil {
+			t = t.In(p.Location)
+		}
+		b.WriteString(t.Format(layout))
+	case "level":
+		b.WriteString(Level(entry.Level).String())
+	case "name":
+		b.WriteString(pString(entry.Data[NameKey]))
+	case "source":
+		source := pString(entry.Data["source"])
+		b.WriteString(source)
+		if function := pString(entry.Data[FuncKey]); function != "" && source != "" {
+			b.WriteString(" " + function)
+		}
+	case "msg":
+		b.WriteString(entry.Message)
+	case "fields":
+		p.appendFields(b, entry.Data)
+	default:
+		return false
+	}
+	return true
+}
+
+// appendFields writes the fields not written by the other tokens, in the
+// order of FieldOrder then sorted by key.
+func (p *PFormatter) appendFields(b *bytes.Buffer, data logrus.Fields) {
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		if !pTokenKeys[k] && p.included(k) {
+			keys = append(keys, k)
+		}
+	}
+	rank := make(map[string]int, len(p.FieldOrder))
+	for i, k := range p.FieldOrder {
+		if _, ok := rank[k]; !ok {
+			rank[k] = i
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		ri, oki := rank[keys[i]]
+		rj, okj := rank[keys[j]]
+		switch {
+		case oki && okj:
+			return ri < rj
+		case oki != okj:
+			return oki
+		}
+		return keys[i] < keys[j]
+	})
+
+	for i, k := range keys {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(k)
+		b.WriteByte('=')
+		appendPValue(b, data[k])
+	}
+}
+
+func (p *PFormatter) included(key string) bool {
+	for _, k := range p.ExcludeFields {
+		if k == key {
+			return false
+		}
+	}
+	if len(p.IncludeFields) == 0 {
+		return true
+	}
+	for _, k := range p.IncludeFields {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// pString returns v as a string, empty if v is nil.
+func pString(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case Field:
+		return v.String()
+	}
+	return fmt.Sprint(v)
+}
+
+// appendPValue writes v, quoted if it is empty or holds spaces, quotes,
+// equal signs or non printable characters.
+func appendPValue(b *bytes.Buffer, v interface{}) {
+	var s string
+	switch v := v.(type) {
+	case Field:
+		var tmp bytes.Buffer
+		v.appendText(&tmp)
+		s = tmp.String()
+	case error:
+		s = v.Error()
+	default:
+		s = pString(v)
+	}
+	if s == "" || strings.IndexFunc(s, func(r rune) bool {
+		return r == '"' || r == '=' || unicode.IsSpace(r) || !unicode.IsPrint(r)
+	}) >= 0 {
+		s = strconv.Quote(s)
+	}
+	b.WriteString(s)
+}
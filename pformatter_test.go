@@ -0,0 +1,94 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestPFormatterFields(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLogger(&buf)
+	l.(logger).entry.Logger.Formatter = PrefixedFormatter
+
+	source := nextLine()
+	l.With("user", "bob").WithError(errors.New("not found")).Named("api").Log(WarnLevel, "lookup failed", Int("status", 404), String("query", "a b"))
+	out := buf.String()
+	if !strings.Contains(out, "[warn][api]["+source+"] lookup failed error=\"not found\" error_type=*errors.errorString query=\"a b\" status=404 user=bob\n") {
+		t.Fatalf("unexpected output %q", out)
+	}
+	if _, err := time.Parse(DefaultPTimeLayout, out[:len(DefaultPTimeLayout)]); err != nil {
+		t.Fatalf("unexpected time in %q: %v", out, err)
+	}
+}
+
+func TestPFormatterMissingFields(t *testing.T) {
+	entry := &logrus.Entry{
+		Data:    logrus.Fields{"source": 42, NameKey: nil, "empty": ""},
+		Time:    time.Date(2024, 3, 1, 8, 30, 0, 123456789, time.UTC),
+		Level:   logrus.InfoLevel,
+		Message: "no source",
+	}
+	b, err := (&PFormatter{}).Format(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(b); got != "2024-03-01 08:30:00 [info][42] no source empty=\"\"\n" {
+		t.Fatalf("unexpected output %q", got)
+	}
+
+	entry.Data = logrus.Fields{}
+	b, _ = (&PFormatter{}).Format(entry)
+	if got := string(b); got != "2024-03-01 08:30:00 [info] no source\n" {
+		t.Fatalf("unexpected output %q", got)
+	}
+
+	b, _ = (&PFormatter{TimeLayout: "2006-01-02 15:04:05.000"}).Format(entry)
+	if got := string(b); got != "2024-03-01 08:30:00.123 [info] no source\n" {
+		t.Fatalf("unexpected output with milliseconds %q", got)
+	}
+}
+
+func TestPFormatterLayout(t *testing.T) {
+	loc := time.FixedZone("CST", 8*3600)
+	p := &PFormatter{
+		Layout:        "{level}|{time}|{unknown}|{msg}|{fields}",
+		TimeLayout:    time.RFC3339,
+		Location:      loc,
+		FieldOrder:    []string{"b", "z"},
+		ExcludeFields: []string{"skip"},
+	}
+	entry := &logrus.Entry{
+		Data:    logrus.Fields{"a": 1, "b": 2, "z": 3, "skip": 4, "source": "main.go:1"},
+		Time:    time.Date(2024, 3, 1, 8, 30, 0, 0, time.UTC),
+		Level:   logrus.ErrorLevel,
+		Message: "custom",
+	}
+	b, _ := p.Format(entry)
+	if got := string(b); got != "error|2024-03-01T16:30:00+08:00|{unknown}|custom|b=2 z=3 a=1\n" {
+		t.Fatalf("unexpected output %q", got)
+	}
+
+	p.IncludeFields = []string{"a", "skip"}
+	b, _ = p.Format(entry)
+	if got := string(b); !strings.HasSuffix(got, "|custom|a=1\n") {
+		t.Fatalf("unexpected output %q", got)
+	}
+}
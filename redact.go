@@ -0,0 +1,220 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// RedactedValue replaces the values of the fields denied by
+// RedactionPolicy.Keys.
+const RedactedValue = "[REDACTED]"
+
+// Redactor masks the sensitive parts of a value. key is the field the
+// value belongs to, logrus.FieldKeyMsg for the message.
+type Redactor interface {
+	Redact(key, value string) string
+}
+
+// RedactorFunc is a function implementing Redactor.
+type RedactorFunc func(key, value string) string
+
+// Redact implements Redactor.
+func (f RedactorFunc) Redact(key, value string) string {
+	return f(key, value)
+}
+
+// NewRegexpRedactor returns a Redactor replacing the matches of re in the
+// values with repl, which can refer to the submatches like
+// regexp.Regexp.ReplaceAllString.
+func NewRegexpRedactor(re *regexp.Regexp, repl string) Redactor {
+	return regexpRedactor{re: re, repl: repl}
+}
+
+// NewRegexpRedactorWithCheck is NewRegexpRedactor replacing only the
+// matches for which check returns true, to tell real sensitive values
+// from numbers that only look like them.
+func NewRegexpRedactorWithCheck(re *regexp.Regexp, repl string, check func(match string) bool) Redactor {
+	return regexpRedactor{re: re, repl: repl, check: check}
+}
+
+type regexpRedactor struct {
+	re    *regexp.Regexp
+	repl  string
+	check func(match string) bool
+}
+
+func (r regexpRedactor) Redact(_, value string) string {
+	if r.check == nil {
+		return r.re.ReplaceAllString(value, r.repl)
+	}
+	var b []byte
+	last := 0
+	for _, m := range r.re.FindAllStringSubmatchIndex(value, -1) {
+		if !r.check(value[m[0]:m[1]]) {
+			continue
+		}
+		b = append(b, value[last:m[0]]...)
+		b = r.re.ExpandString(b, r.repl, value, m)
+		last = m[1]
+	}
+	if last == 0 && b == nil {
+		return value
+	}
+	return string(append(b, value[last:]...))
+}
+
+// luhnValid reports whether the digits of s pass the Luhn checksum of
+// the bank card numbers.
+func luhnValid(s string) bool {
+	sum := 0
+	for i := 0; i < len(s); i++ {
+		d := int(s[len(s)-1-i] - '0')
+		if i%2 == 1 {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return sum%10 == 0
+}
+
+// The built-in redactors, they keep enough of the value to tell two of
+// them apart.
+var (
+	// RedactEmails masks the local part of email addresses but its first
+	// letter: a***@example.com.
+	RedactEmails = NewRegexpRedactor(regexp.MustCompile(`\b([A-Za-z0-9])[A-Za-z0-9._%+-]*@([A-Za-z0-9.-]+\.[A-Za-z]{2,})\b`), "$1***@$2")
+	// RedactIDCards masks the date of birth of the 18 character Chinese
+	// resident ID numbers: 110101********123X.
+	RedactIDCards = NewRegexpRedactor(regexp.MustCompile(`\b(\d{6})(?:19|20)\d{2}(?:0[1-9]|1[0-2])(?:0[1-9]|[12]\d|3[01])(\d{3}[\dXx])\b`), "$1********$2")
+	// RedactPhones masks the middle digits of Chinese mobile phone numbers:
+	// 138****5678.
+	RedactPhones = NewRegexpRedactor(regexp.MustCompile(`\b(1[3-9]\d)\d{4}(\d{4})\b`), "$1****$2")
+	// RedactBankCards masks bank card numbers of 16 to 19 digits but their
+	// last 4 digits: ****1234. The numbers failing the Luhn checksum, like
+	// most order or transaction IDs, are kept.
+	RedactBankCards = NewRegexpRedactorWithCheck(regexp.MustCompile(`\b\d{12,15}(\d{4})\b`), "****$1", luhnValid)
+)
+
+// RedactionPolicy configures SetRedaction.
+type RedactionPolicy struct {
+	// Keys are the fields whose value is replaced by RedactedValue,
+	// whatever its type. They are compared ignoring case.
+	Keys []string
+	// Redactors are applied in order to the message and to the string
+	// values of the other fields, errors included. The values nested in
+	// maps, structs or slices other than []string are not redacted, log
+	// them as separate fields or deny their key.
+	Redactors []Redactor
+}
+
+// DefaultRedactionPolicy returns a policy denying the usual credential
+// fields and masking emails, ID card, phone and bank card numbers.
+func DefaultRedactionPolicy() *RedactionPolicy {
+	return &RedactionPolicy{
+		Keys: []string{"password", "passwd", "secret", "token", "access_token", "refresh_token",
+			"api_key", "apikey", "authorization", "cookie", "set-cookie"},
+		// ID cards first, they would match the bank card pattern
+		Redactors: []Redactor{RedactEmails, RedactIDCards, RedactPhones, RedactBankCards},
+	}
+}
+
+// SetRedaction sets the redaction policy of the standard logger, see
+// Logger.SetRedaction.
+func SetRedaction(policy *RedactionPolicy) {
+	baseLogger.SetRedaction(policy)
+}
+
+// SetRedaction makes the logger redact the entries according to policy
+// before they reach the formatter and the hooks, so the output, rotated
+// files, Sentry and Graylog all receive the redacted entries. A nil
+// policy disables redaction.
+func (l logger) SetRedaction(policy *RedactionPolicy) {
+	var r *redactor
+	if policy != nil {
+		r = &redactor{keys: make(map[string]bool, len(policy.Keys)), redactors: policy.Redactors}
+		for _, k := range policy.Keys {
+			r.keys[strings.ToLower(k)] = true
+		}
+	}
+	l.core.redactor.Store(r)
+}
+
+type redactor struct {
+	keys      map[string]bool
+	redactors []Redactor
+}
+
+// redact redacts the message and the fields of entry in place.
+func (c *core) redact(entry *logrus.Entry) {
+	r, _ := c.redactor.Load().(*redactor)
+	if r == nil && c.parent != nil {
+		r, _ = c.parent.redactor.Load().(*redactor)
+	}
+	if r == nil {
+		return
+	}
+	entry.Message = r.redactString(logrus.FieldKeyMsg, entry.Message)
+	for k, v := range entry.Data {
+		if r.keys[strings.ToLower(k)] {
+			entry.Data[k] = RedactedValue
+			continue
+		}
+		entry.Data[k] = r.value(k, v)
+	}
+}
+
+func (r *redactor) redactString(key, s string) string {
+	for _, red := range r.redactors {
+		s = red.Redact(key, s)
+	}
+	return s
+}
+
+// value returns v redacted, v itself if it holds no string.
+func (r *redactor) value(key string, v interface{}) interface{} {
+	switch v := v.(type) {
+	case string:
+		return r.redactString(key, v)
+	case []string:
+		redacted := make([]string, len(v))
+		for i, s := range v {
+			redacted[i] = r.redactString(key, s)
+		}
+		return redacted
+	case Field:
+		if v.typ == stringType || v.typ == stringerType {
+			return String(v.Key, r.redactString(key, v.String()))
+		}
+	case error:
+		if msg := r.redactString(key, v.Error()); msg != v.Error() {
+			return redactedError(msg)
+		}
+	}
+	return v
+}
+
+// redactedError replaces an error whose message was redacted. It does not
+// wrap the original error, hooks unwrapping it with pkg/errors.Cause
+// would find the original message.
+type redactedError string
+
+func (e redactedError) Error() string {
+	return string(e)
+}
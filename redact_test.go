@@ -0,0 +1,96 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestRedactors(t *testing.T) {
+	r := DefaultRedactionPolicy()
+	for _, tc := range []struct {
+		in, want string
+	}{
+		{"mail alice.smith@example.com now", "mail a***@example.com now"},
+		{"id 11010119900307123X", "id 110101********123X"},
+		{"call 13812345678 or 023-1234", "call 138****5678 or 023-1234"},
+		{"card 6222021234567890128", "card ****0128"},
+		{"cards 4111111111111111,4111111111111111", "cards ****1111,****1111"},
+		{"txn 6222021234567890123", "txn 6222021234567890123"},
+		{"order 20240101", "order 20240101"},
+	} {
+		got := tc.in
+		for _, red := range r.Redactors {
+			got = red.Redact("msg", got)
+		}
+		if got != tc.want {
+			t.Errorf("redacting %q = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestSetRedaction(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLogger(&buf)
+	h := &lastEntryHook{}
+	l.AddHook(h, InfoLevel)
+	l.SetRedaction(DefaultRedactionPolicy())
+
+	l.With("Password", "hunter2").With("user", "bob@example.com").
+		WithError(errors.New("no account for 13812345678")).
+		Log(InfoLevel, "login of 13812345678", String("Authorization", "Bearer abc"), Stringer("phone", stringer("13812345678")))
+	out := buf.String()
+	for _, leak := range []string{"hunter2", "bob@", "Bearer", "13812345678"} {
+		if strings.Contains(out, leak) {
+			t.Fatalf("%s not redacted in %q", leak, out)
+		}
+	}
+	for _, want := range []string{`Password="[REDACTED]"`, `user="b***@example.com"`, `error="no account for 138****5678"`, `msg="login of 138****5678"`, `phone="138****5678"`} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("%s not found in %q", want, out)
+		}
+	}
+	fired := h.entry
+	if fired == nil || fired.Data["Password"] != RedactedValue || fired.Data[logrus.ErrorKey].(error).Error() != "no account for 138****5678" {
+		t.Fatalf("hook received %v", fired)
+	}
+
+	buf.Reset()
+	l.SetRedaction(nil)
+	l.Info("call 13812345678")
+	if !strings.Contains(buf.String(), "13812345678") {
+		t.Fatalf("redaction not disabled: %q", buf.String())
+	}
+}
+
+// lastEntryHook keeps the last entry it received.
+type lastEntryHook struct {
+	entry *logrus.Entry
+}
+
+func (h *lastEntryHook) Levels() []logrus.Level { return logrus.AllLevels }
+
+func (h *lastEntryHook) Fire(entry *logrus.Entry) error {
+	h.entry = entry
+	return nil
+}
+
+type stringer string
+
+func (s stringer) String() string { return string(s) }
@@ -0,0 +1,49 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log_test
+
+import (
+	"bytes"
+	stdlog "log"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/lwhile/log"
+)
+
+// The source found on the stack is the first frame outside the log
+// packages, so the redirection is tested from outside this package.
+func TestRedirectStdLogSourceMode(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+	log.SetSourceMode(log.SourceFull)
+	defer log.SetSourceMode(log.SourceShort)
+
+	stdlog.SetPrefix("legacy: ")
+	undo := log.RedirectStdLog(log.InfoLevel)
+	stdlog.Print("legacy: mode enabled")
+	undo()
+	stdlog.SetPrefix("")
+
+	out := buf.String()
+	if !strings.Contains(out, "legacy: mode enabled") {
+		t.Fatalf("message changed in %q", out)
+	}
+	// the stack gives the full path, the Lshortfile flag only the base name
+	if !strings.Contains(out, string(os.PathSeparator)+"redirect_test.go:") {
+		t.Fatalf("source mode ignored in %q", out)
+	}
+}
@@ -0,0 +1,252 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// SampledMessageKey and DroppedKey are the fields of the summary entries
+// logged for the entries dropped by sampling.
+const (
+	SampledMessageKey = "sampled_msg"
+	DroppedKey        = "dropped"
+)
+
+// SamplingPolicy caps the number of entries logged with the same level and
+// message. The printf style methods are sampled by format, the others by
+// message.
+type SamplingPolicy struct {
+	// First entries are logged in each interval.
+	First int
+	// Thereafter every Thereafter-th entry is logged, none if zero.
+	Thereafter int
+	// Interval is the period after which the counts start over, one
+	// second if zero.
+	Interval time.Duration
+}
+
+// SetSampling sets the sampling policy of level on the standard logger,
+// see Logger.SetSampling.
+func SetSampling(level Level, policy *SamplingPolicy) {
+	baseLogger.SetSampling(level, policy)
+}
+
+// SetHookSampled sets whether the hook with the given id of the standard
+// logger only receives the entries kept by sampling.
+func SetHookSampled(id int, sampled bool) error {
+	return baseLogger.SetHookSampled(id, sampled)
+}
+
+// SetSampling sets the sampling policy of level, nil disables sampling at
+// that level. Sampling applies to the output and to every hook but the
+// ones exempted with SetHookSampled. The number of entries dropped for
+// each message is logged periodically in a summary entry at the same
+// level, with the sampled_msg and dropped fields.
+//
+// Panic and Fatal entries are never sampled. Changing a policy resets the
+// counts of all levels.
+func (l logger) SetSampling(level Level, policy *SamplingPolicy) {
+	if level <= FatalLevel || level > TraceLevel {
+		return
+	}
+	l.core.samplingMu.Lock()
+	defer l.core.samplingMu.Unlock()
+
+	var policies [TraceLevel + 1]*SamplingPolicy
+	old, _ := l.core.sampler.Load().(*sampler)
+	if old != nil {
+		policies = old.policies
+		l.core.summarize(old)
+	}
+	if policy != nil {
+		p := *policy
+		if p.Interval <= 0 {
+			p.Interval = time.Second
+		}
+		policy = &p
+	}
+	policies[level] = policy
+
+	var s *sampler
+	for _, p := range policies {
+		if p != nil {
+			s = newSampler(policies)
+			break
+		}
+	}
+	l.core.sampler.Store(s)
+}
+
+// SetHookSampled sets whether the hook with the given id only receives the
+// entries kept by sampling, which is the default. A hook that is not
+// sampled receives every entry at its level, e.g. so that Sentry sees all
+// the occurrences of an error.
+func (l logger) SetHookSampled(id int, sampled bool) error {
+	return l.core.setHookSampled(id, sampled)
+}
+
+type sampleKey struct {
+	level Level
+	msg   string
+}
+
+type sampleCounter struct {
+	// start of the current interval in nanoseconds, count of the entries
+	// in it and number of entries dropped since the last summary
+	start   int64
+	count   uint64
+	dropped uint64
+}
+
+type sampler struct {
+	// tick is the shortest interval, summaries are logged at that pace
+	tick        int64
+	nextSummary int64
+
+	policies [TraceLevel + 1]*SamplingPolicy
+
+	mu       sync.RWMutex
+	counters map[sampleKey]*sampleCounter
+}
+
+func newSampler(policies [TraceLevel + 1]*SamplingPolicy) *sampler {
+	s := &sampler{policies: policies, counters: make(map[sampleKey]*sampleCounter)}
+	for _, p := range policies {
+		if p != nil && (s.tick == 0 || int64(p.Interval) < s.tick) {
+			s.tick = int64(p.Interval)
+		}
+	}
+	s.nextSummary = time.Now().UnixNano() + s.tick
+	return s
+}
+
+func (s *sampler) counter(key sampleKey) *sampleCounter {
+	s.mu.RLock()
+	c, ok := s.counters[key]
+	s.mu.RUnlock()
+	if ok {
+		return c
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if c, ok = s.counters[key]; !ok {
+		c = &sampleCounter{}
+		s.counters[key] = c
+	}
+	return c
+}
+
+// keep counts an entry and reports whether it should be logged.
+func (s *sampler) keep(level Level, msg string, now int64) bool {
+	p := s.policies[level]
+	c := s.counter(sampleKey{level, msg})
+
+	var n uint64
+	start := atomic.LoadInt64(&c.start)
+	if now >= start+int64(p.Interval) && atomic.CompareAndSwapInt64(&c.start, start, now) {
+		atomic.StoreUint64(&c.count, 1)
+		n = 1
+	} else {
+		n = atomic.AddUint64(&c.count, 1)
+	}
+
+	first := uint64(p.First)
+	if n <= first || (p.Thereafter > 0 && (n-first)%uint64(p.Thereafter) == 0) {
+		return true
+	}
+	atomic.AddUint64(&c.dropped, 1)
+	return false
+}
+
+// sample reports whether an entry with the given level and message should
+// be logged, and logs the summaries that are due.
+func (c *core) sample(level Level, msg string) bool {
+	s, _ := c.sampler.Load().(*sampler)
+	if s == nil || level > TraceLevel || s.policies[level] == nil {
+		return true
+	}
+	now := time.Now().UnixNano()
+	keep := s.keep(level, msg, now)
+	if next := atomic.LoadInt64(&s.nextSummary); now >= next && atomic.CompareAndSwapInt64(&s.nextSummary, next, now+s.tick) {
+		c.summarize(s)
+	}
+	return keep
+}
+
+// summarize logs the number of entries dropped for every message since
+// the last summary and releases the counters of the messages that were
+// not logged recently.
+func (c *core) summarize(s *sampler) {
+	type summary struct {
+		key     sampleKey
+		dropped uint64
+	}
+	var summaries []summary
+	now := time.Now().UnixNano()
+
+	s.mu.Lock()
+	for key, counter := range s.counters {
+		if dropped := atomic.SwapUint64(&counter.dropped, 0); dropped > 0 {
+			summaries = append(summaries, summary{key, dropped})
+		} else if now-atomic.LoadInt64(&counter.start) > 2*int64(s.policies[key.level].Interval) {
+			delete(s.counters, key)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, sum := range summaries {
+		entry := &logrus.Entry{Logger: c.logger, Data: logrus.Fields{
+			SampledMessageKey: sum.key.msg,
+			DroppedKey:        sum.dropped,
+		}}
+		c.write(entry, sum.key.level, "entries dropped by sampling")
+	}
+}
+
+// flushSampling logs the pending summaries, it is called on shutdown.
+func (c *core) flushSampling() {
+	if s, _ := c.sampler.Load().(*sampler); s != nil {
+		c.summarize(s)
+	}
+}
+
+// hasUnsampledHooks reports whether some hooks want the entries at level
+// that were dropped by sampling.
+func (c *core) hasUnsampledHooks(level Level) bool {
+	c.hooksMu.RLock()
+	defer c.hooksMu.RUnlock()
+	return len(c.unsampled[logrus.Level(level)]) > 0
+}
+
+// fireUnsampled fires the hooks exempted from sampling for an entry that
+// was dropped.
+func (c *core) fireUnsampled(entry *logrus.Entry, level Level, msg string) {
+	entry.Time = time.Now()
+	entry.Level = logrus.Level(level)
+	entry.Message = msg
+	c.redact(entry)
+
+	c.hooksMu.RLock()
+	err := c.unsampled.Fire(entry.Level, entry)
+	c.hooksMu.RUnlock()
+	if err != nil {
+		c.hookError(err)
+	}
+}
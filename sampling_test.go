@@ -0,0 +1,94 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSampling(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLogger(&buf)
+	sampled, unsampled := &countHook{}, &countHook{}
+	if _, err := l.AddHook(sampled, WarnLevel); err != nil {
+		t.Fatal(err)
+	}
+	info, err := l.AddHook(unsampled, WarnLevel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.SetHookSampled(info.ID, false); err != nil {
+		t.Fatal(err)
+	}
+
+	l.SetSampling(WarnLevel, &SamplingPolicy{First: 2, Thereafter: 3, Interval: time.Hour})
+	for i := 0; i < 10; i++ {
+		l.Warnf("retry %d", i)
+		l.Error("not sampled")
+	}
+	out := buf.String()
+	for _, i := range []string{"0", "1", "4", "7"} {
+		if !strings.Contains(out, `msg="retry `+i+`"`) {
+			t.Fatalf("entry %s was not logged in %q", i, out)
+		}
+	}
+	if n := strings.Count(out, "retry"); n != 4 {
+		t.Fatalf("%d entries logged instead of 4 in %q", n, out)
+	}
+	if n := strings.Count(out, "not sampled"); n != 10 {
+		t.Fatalf("%d error entries logged instead of 10", n)
+	}
+	if sampled.count() != 14 || unsampled.count() != 20 {
+		t.Fatalf("unexpected hook counts %d, %d", sampled.count(), unsampled.count())
+	}
+
+	buf.Reset()
+	if err := l.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if out := buf.String(); !strings.Contains(out, "level=warning") || !strings.Contains(out, `sampled_msg="retry %d"`) || !strings.Contains(out, "dropped=6") {
+		t.Fatalf("unexpected summary %q", out)
+	}
+
+	buf.Reset()
+	l.SetSampling(WarnLevel, nil)
+	for i := 0; i < 10; i++ {
+		l.Warn("unlimited")
+	}
+	if n := strings.Count(buf.String(), "unlimited"); n != 10 {
+		t.Fatalf("%d entries logged instead of 10", n)
+	}
+}
+
+func TestSamplingInterval(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLogger(&buf)
+	l.SetSampling(InfoLevel, &SamplingPolicy{First: 1, Interval: 20 * time.Millisecond})
+
+	l.Info("tick")
+	l.Info("tick")
+	time.Sleep(30 * time.Millisecond)
+	l.Info("tick")
+	out := buf.String()
+	if n := strings.Count(out, ` msg=tick`); n != 2 {
+		t.Fatalf("%d entries logged instead of 2 in %q", n, out)
+	}
+	if !strings.Contains(out, "dropped=1 sampled_msg=tick") {
+		t.Fatalf("summary not logged in %q", out)
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// FatalShutdownTimeout bounds the time the Fatal functions wait for the
+// hooks to deliver the fatal entry before exiting.
+var FatalShutdownTimeout = 5 * time.Second
+
+// SetExitFunc sets the function called by the standard logger after
+// logging a Fatal entry, see Logger.SetExitFunc.
+func SetExitFunc(fn func(code int)) {
+	baseLogger.SetExitFunc(fn)
+}
+
+// SetExitFunc sets the function called with the exit code 1 by the Fatal
+// methods once the hooks are shut down, after the entry is logged if level
+// Fatal is enabled. A nil fn restores the
+// default which runs the logrus exit handlers and exits the program.
+// Tests replace it to check the fatal paths.
+func (l logger) SetExitFunc(fn func(code int)) {
+	if fn == nil {
+		fn = logrus.Exit
+	}
+	l.core.exit.Store(fn)
+}
+
+func (c *core) exitFunc() func(int) {
+	if fn, _ := c.exit.Load().(func(int)); fn != nil {
+		return fn
+	}
+	return logrus.Exit
+}
+
+// String returns a short description of the hook for error messages.
+func (h HookInfo) String() string {
+	if h.Target == "" {
+		return fmt.Sprintf("%s hook %d", h.Kind, h.ID)
+	}
+	return fmt.Sprintf("%s hook %d (%s)", h.Kind, h.ID, h.Target)
+}
+
+// Shutdown flushes the asynchronous Sentry and Graylog hooks of the
+// standard logger and of the loggers created with NewAccessLogger, and
+// closes the files written by their rotate hooks. It returns when done or
+// when ctx expires, with the errors of every hook.
+func Shutdown(ctx context.Context) error {
+	return baseLogger.Shutdown(ctx)
+}
+
+// Shutdown waits for the entries queued by the async dispatcher, then
+// flushes every hook registered on the logger and closes the files they
+// write to. Rotate hooks open their file again if the logger is used
+// afterwards.
+func (l logger) Shutdown(ctx context.Context) error {
+	return l.core.shutdown(ctx)
+}
+
+func (c *core) shutdown(ctx context.Context) error {
+	errs := []error{c.shutdownHooks(ctx)}
+	c.childrenMu.Lock()
+	children := append([]*core(nil), c.children...)
+	c.childrenMu.Unlock()
+	for _, child := range children {
+		errs = append(errs, child.shutdown(ctx))
+	}
+	return errors.Join(errs...)
+}
+
+func (c *core) shutdownHooks(ctx context.Context) error {
+	c.flushSampling()
+	c.flushDedup()
+	if err := c.drainAsync(ctx); err != nil {
+		return fmt.Errorf("entries not written: %w", err)
+	}
+
+	c.hooksMu.RLock()
+	hooks := append([]*hookEntry(nil), c.hooks...)
+	c.hooksMu.RUnlock()
+
+	type result struct {
+		info HookInfo
+		err  error
+	}
+	// the hooks are flushed concurrently so that a slow one does not use
+	// up the deadline of the others
+	results := make(chan result, len(hooks))
+	for _, h := range hooks {
+		go func(h *hookEntry) {
+			results <- result{info: h.HookInfo, err: h.close()}
+		}(h)
+	}
+
+	var errs []error
+	for pending := len(hooks); pending > 0; pending-- {
+		select {
+		case r := <-results:
+			if r.err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", r.info, r.err))
+			}
+		case <-ctx.Done():
+			errs = append(errs, fmt.Errorf("%d hooks not flushed: %w", pending, ctx.Err()))
+			return errors.Join(errs...)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// close flushes the hook if it buffers entries and closes its file.
+func (h *hookEntry) close() error {
+	if f, ok := h.hook.(interface{ Flush() }); ok {
+		f.Flush()
+	}
+	if h.writer != nil {
+		return h.writer.Close()
+	}
+	return nil
+}
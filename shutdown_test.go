@@ -0,0 +1,109 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type flushHook struct {
+	delay   time.Duration
+	flushed bool
+}
+
+func (h *flushHook) Levels() []logrus.Level     { return logrus.AllLevels }
+func (h *flushHook) Fire(e *logrus.Entry) error { return nil }
+func (h *flushHook) Flush() {
+	time.Sleep(h.delay)
+	h.flushed = true
+}
+
+func TestShutdown(t *testing.T) {
+	l := NewLogger(ioutil.Discard).(logger)
+	path := filepath.Join(t.TempDir(), "app.log")
+	if err := l.AddRotateHookByDay(path, 1, 1, InfoLevel); err != nil {
+		t.Fatal(err)
+	}
+	fh := &flushHook{}
+	l.core.addHook(&hookEntry{HookInfo: HookInfo{Kind: "test", Level: InfoLevel}, hook: fh})
+
+	l.Info("before shutdown")
+	if err := l.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !fh.flushed {
+		t.Fatal("hook was not flushed")
+	}
+
+	// the file is opened again when logging after a shutdown
+	l.Info("after shutdown")
+	b, err := ioutil.ReadFile(l.core.hooks[0].writer.CurrentFileName())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "after shutdown") {
+		t.Fatalf("unexpected content %q", b)
+	}
+}
+
+func TestShutdownDeadline(t *testing.T) {
+	l := NewLogger(ioutil.Discard).(logger)
+	l.core.addHook(&hookEntry{HookInfo: HookInfo{Kind: "slow", Level: InfoLevel}, hook: &flushHook{delay: time.Second}})
+	l.core.addHook(&hookEntry{HookInfo: HookInfo{Kind: "fast", Level: InfoLevel}, hook: &flushHook{}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := l.Shutdown(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if !strings.Contains(err.Error(), "1 hooks not flushed") {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestFatalExitsWhenDisabled(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLogger(&buf)
+	l.SetLevel(PanicLevel)
+	var codes []int
+	l.SetExitFunc(func(code int) { codes = append(codes, code) })
+
+	l.Fatal("fatal")
+	l.Fatalf("%s", "fatalf")
+	l.Fatalw("fatalw")
+	l.Log(FatalLevel, "log")
+	if len(codes) != 4 || codes[0] != 1 {
+		t.Fatalf("unexpected exit codes %v", codes)
+	}
+	if buf.Len() != 0 {
+		t.Fatalf("disabled fatal entries written: %q", buf.String())
+	}
+
+	l.SetLevel(InfoLevel)
+	codes = nil
+	l.Fatal("enabled")
+	if len(codes) != 1 || !strings.Contains(buf.String(), "enabled") {
+		t.Fatalf("unexpected exit codes %v and output %q", codes, buf.String())
+	}
+}
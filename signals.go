@@ -0,0 +1,62 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import "fmt"
+
+// handleSignals is nil if the target OS does not support SIGUSR1 and SIGUSR2.
+var handleSignals func(l logger, levels []Level) func()
+
+// HandleSignals makes the standard logger react to signals until stop is
+// called:
+//
+// * SIGUSR1 moves to the next level of levels, wrapping around.
+// * SIGUSR2 moves to the previous level of levels, wrapping around.
+// * SIGHUP reopens the files written by the AddRotateHook* family of every
+// logger, including the ones created with NewLogger and NewAccessLogger, so
+// that logrotate with the create or copytruncate policies works.
+//
+// levels defaults to [info, debug], so that SIGUSR1 toggles between info
+// and debug. Every transition is logged, on stderr when
+// the logger writes neither info nor error entries before or after it.
+func HandleSignals(levels ...Level) (stop func(), err error) {
+	if handleSignals == nil {
+		return nil, fmt.Errorf("system does not support log signals")
+	}
+	if len(levels) == 0 {
+		levels = []Level{InfoLevel, DebugLevel}
+	}
+	return handleSignals(baseLogger, levels), nil
+}
+
+// cycleLevel returns the level step positions away from cur in levels.
+// If cur is not in levels, the first level is used for a step forward and
+// the last one for a step backward.
+func cycleLevel(levels []Level, cur Level, step int) Level {
+	i := -1
+	for j, l := range levels {
+		if l == cur {
+			i = j
+			break
+		}
+	}
+	if i < 0 {
+		if step > 0 {
+			return levels[0]
+		}
+		return levels[len(levels)-1]
+	}
+	n := len(levels)
+	return levels[((i+step)%n+n)%n]
+}
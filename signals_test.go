@@ -0,0 +1,38 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import "testing"
+
+func TestCycleLevel(t *testing.T) {
+	levels := []Level{InfoLevel, DebugLevel, TraceLevel}
+	testCases := []struct {
+		cur  Level
+		step int
+		want Level
+	}{
+		{InfoLevel, 1, DebugLevel},
+		{DebugLevel, 1, TraceLevel},
+		{TraceLevel, 1, InfoLevel},
+		{InfoLevel, -1, TraceLevel},
+		{DebugLevel, -1, InfoLevel},
+		{WarnLevel, 1, InfoLevel},
+		{WarnLevel, -1, TraceLevel},
+	}
+	for _, tc := range testCases {
+		if got := cycleLevel(levels, tc.cur, tc.step); got != tc.want {
+			t.Errorf("cycleLevel(%s, %d) = %s, want %s", tc.cur, tc.step, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !windows,!nacl,!plan9
+
+package log
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+func init() {
+	handleSignals = func(l logger, levels []Level) func() {
+		ch := make(chan os.Signal, 1)
+		done := make(chan struct{})
+		signal.Notify(ch, syscall.SIGUSR1, syscall.SIGUSR2, syscall.SIGHUP)
+
+		go func() {
+			for {
+				select {
+				case <-done:
+					return
+				case sig := <-ch:
+					handleSignal(l, levels, sig)
+				}
+			}
+		}()
+
+		return func() {
+			signal.Stop(ch)
+			close(done)
+		}
+	}
+}
+
+func handleSignal(l logger, levels []Level, sig os.Signal) {
+	switch sig {
+	case syscall.SIGHUP:
+		if err := rotateFiles.reopen(); err != nil {
+			l.Errorf("%v", err)
+			return
+		}
+		l.Infof("log files reopened on %s", sig)
+	case syscall.SIGUSR1, syscall.SIGUSR2:
+		step := 1
+		if sig == syscall.SIGUSR2 {
+			step = -1
+		}
+		prev := l.core.level()
+		next := cycleLevel(levels, prev, step)
+		// log at info, or at error if info is disabled before or after
+		// the change, but never at a level that would exit or panic. The
+		// entry is written before the change if it is enabled then, so
+		// that raising the level to fatal or panic is not lost.
+		level := InfoLevel
+		if prev < level || next < level {
+			level = ErrorLevel
+		}
+		logChange := func() {
+			l.Log(level, "log level changed", String("from", prev.String()), String("to", next.String()), Stringer("signal", sig))
+		}
+		switch {
+		case level <= prev:
+			logChange()
+			l.core.setLevel(next)
+		case level <= next:
+			l.core.setLevel(next)
+			logChange()
+		default:
+			l.core.setLevel(next)
+			fmt.Fprintf(os.Stderr, "log level changed from %s to %s on %s\n", prev, next, sig)
+		}
+	}
+}
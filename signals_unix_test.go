@@ -0,0 +1,96 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !windows,!nacl,!plan9
+
+package log
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestHandleSignalsLevel(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLogger(&buf).(logger)
+	l.core.setLevel(InfoLevel)
+
+	stop := handleSignals(l, []Level{InfoLevel, DebugLevel})
+	defer stop()
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGUSR1); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for l.core.level() != DebugLevel {
+		if time.Now().After(deadline) {
+			t.Fatalf("level %s was not changed", l.core.level())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	handleSignal(l, []Level{InfoLevel, DebugLevel}, syscall.SIGUSR1)
+	if l.core.level() != InfoLevel {
+		t.Fatalf("level %s != %s", l.core.level(), InfoLevel)
+	}
+	if !strings.Contains(buf.String(), "from=debug") || !strings.Contains(buf.String(), "to=info") {
+		t.Fatalf("transition not logged in %q", buf.String())
+	}
+}
+
+func TestHandleSignalsFatal(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLogger(&buf).(logger)
+	l.core.setLevel(InfoLevel)
+
+	handleSignal(l, []Level{InfoLevel, FatalLevel}, syscall.SIGUSR1)
+	if l.core.level() != FatalLevel {
+		t.Fatalf("level %s != %s", l.core.level(), FatalLevel)
+	}
+	if out := buf.String(); !strings.Contains(out, "level=error") || !strings.Contains(out, "from=info") || !strings.Contains(out, "to=fatal") {
+		t.Fatalf("transition not logged in %q", out)
+	}
+}
+
+func TestHandleSignalsReopen(t *testing.T) {
+	l := NewLogger(ioutil.Discard).(logger)
+	path := filepath.Join(t.TempDir(), "app.log")
+	if err := l.AddRotateHookByDay(path, 1, 1, InfoLevel); err != nil {
+		t.Fatal(err)
+	}
+
+	l.Info("before rotation")
+	file := l.core.hooks[0].writer.CurrentFileName()
+	if err := os.Rename(file, file+".1"); err != nil {
+		t.Fatal(err)
+	}
+
+	// the signals are handled by an other logger, as the standard logger
+	// handles them for the loggers created with NewLogger
+	handleSignal(NewLogger(ioutil.Discard).(logger), nil, syscall.SIGHUP)
+	l.Info("after rotation")
+
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "after rotation") || strings.Contains(string(b), "before rotation") {
+		t.Fatalf("unexpected content %q", b)
+	}
+}
@@ -0,0 +1,292 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.21
+// +build go1.21
+
+package log
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// The slog levels of Trace, Fatal and Panic. The other levels map to the
+// slog level of the same name.
+const (
+	SlogLevelTrace = slog.Level(-8)
+	SlogLevelFatal = slog.Level(12)
+	SlogLevelPanic = slog.Level(16)
+)
+
+// SlogLevel returns the slog level of level.
+func SlogLevel(level Level) slog.Level {
+	switch level {
+	case PanicLevel:
+		return SlogLevelPanic
+	case FatalLevel:
+		return SlogLevelFatal
+	case ErrorLevel:
+		return slog.LevelError
+	case WarnLevel:
+		return slog.LevelWarn
+	case InfoLevel:
+		return slog.LevelInfo
+	case DebugLevel:
+		return slog.LevelDebug
+	}
+	return SlogLevelTrace
+}
+
+// LevelFromSlog returns the level of the slog level l, the levels between
+// two slog levels map to the least severe of them.
+func LevelFromSlog(l slog.Level) Level {
+	switch {
+	case l >= SlogLevelPanic:
+		return PanicLevel
+	case l >= SlogLevelFatal:
+		return FatalLevel
+	case l >= slog.LevelError:
+		return ErrorLevel
+	case l >= slog.LevelWarn:
+		return WarnLevel
+	case l >= slog.LevelInfo:
+		return InfoLevel
+	case l >= slog.LevelDebug:
+		return DebugLevel
+	}
+	return TraceLevel
+}
+
+// SlogHandler returns a slog.Handler writing to the standard logger.
+func SlogHandler() slog.Handler {
+	return NewSlogHandler(baseLogger)
+}
+
+// NewSlogHandler returns a slog.Handler writing to l, so that the records
+// go through its level, hooks and formatter. The source field is the
+// location of the slog call. Groups are added as fields holding the
+// logrus.Fields of their attributes, so that the JSON formatter nests them
+// like the slog JSON handler and SlogHook turns them back into groups.
+//
+// Records at SlogLevelFatal or above are logged at level Fatal and exit
+// the program, records at SlogLevelPanic or above panic after being
+// logged.
+//
+// The fields extracted from the context passed to the slog call are added
+// to the ones of the context bound to l with WithContext.
+func NewSlogHandler(l Logger) slog.Handler {
+	return &slogHandler{l: loggerOf(l)}
+}
+
+type slogHandler struct {
+	l      logger
+	groups []string
+}
+
+func (h *slogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.l.IsEnabled(LevelFromSlog(level))
+}
+
+func (h *slogHandler) Handle(ctx context.Context, r slog.Record) error {
+	level := LevelFromSlog(r.Level)
+	l := h.l
+	sampled := l.core.sample(level, r.Message)
+	if !sampled && !l.core.hasUnsampledHooks(level) {
+		return nil
+	}
+
+	entry := l.newEntry()
+	if ctx != nil && ctx != l.ctx {
+		for k, v := range contextFields(ctx) {
+			entry.Data[k] = v
+		}
+	}
+	if mode := l.core.sourceMode(); mode&sourcePathMask != SourceNone && r.PC != 0 {
+		source, function := pcSource(r.PC, mode)
+		entry.Data["source"] = source
+		if function != "" {
+			entry.Data[FuncKey] = function
+		}
+	}
+	if r.NumAttrs() > 0 {
+		fields := slogGroup(entry.Data, h.groups)
+		r.Attrs(func(a slog.Attr) bool {
+			addSlogAttr(fields, a)
+			return true
+		})
+	}
+	if l.core.stackEnabled(level) {
+		entry.Data[StacktraceKey] = stackFrom(entry, r.PC)
+	}
+
+	if !sampled {
+		l.core.fireUnsampled(entry, level, r.Message)
+		return nil
+	}
+	l.core.write(entry, level, r.Message)
+	if level == PanicLevel {
+		panic(r.Message)
+	}
+	return nil
+}
+
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	fields := make(logrus.Fields, len(attrs))
+	if len(h.groups) > 0 {
+		if v, ok := h.l.entry.Data[h.groups[0]]; ok {
+			fields[h.groups[0]] = v
+		}
+	}
+	group := slogGroup(fields, h.groups)
+	for _, a := range attrs {
+		addSlogAttr(group, a)
+	}
+	h2 := *h
+	h2.l.entry = h.l.entry.WithFields(fields)
+	return &h2
+}
+
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.groups = append(h.groups[:len(h.groups):len(h.groups)], name)
+	return &h2
+}
+
+// slogGroup returns the fields of the group nested in fields along the
+// path groups. The maps of the groups are copied as they may be shared
+// with the entries of other records.
+func slogGroup(fields logrus.Fields, groups []string) logrus.Fields {
+	for _, name := range groups {
+		group := make(logrus.Fields)
+		if prev, ok := fields[name].(logrus.Fields); ok {
+			for k, v := range prev {
+				group[k] = v
+			}
+		}
+		fields[name] = group
+		fields = group
+	}
+	return fields
+}
+
+// addSlogAttr adds a to fields, a group is added as the logrus.Fields of
+// its attributes, inlined when its key is empty.
+func addSlogAttr(fields logrus.Fields, a slog.Attr) {
+	v := a.Value.Resolve()
+	if v.Kind() == slog.KindGroup {
+		attrs := v.Group()
+		if len(attrs) == 0 {
+			return
+		}
+		if a.Key != "" {
+			fields = slogGroup(fields, []string{a.Key})
+		}
+		for _, ga := range attrs {
+			addSlogAttr(fields, ga)
+		}
+		return
+	}
+	if a.Key == "" && v.Any() == nil {
+		return
+	}
+	fields[a.Key] = v.Any()
+}
+
+// stackFrom returns the stack of the error of entry if any, the stack of
+// the goroutine from the frame of pc otherwise.
+func stackFrom(entry *logrus.Entry, pc uintptr) Stacktrace {
+	st := stacktrace(entry, 1)
+	if err, ok := entry.Data[logrus.ErrorKey].(error); (ok && errorStack(err) != nil) || pc == 0 {
+		return st
+	}
+	for i, p := range st {
+		if p == pc {
+			return st[i:]
+		}
+	}
+	return st
+}
+
+// SlogHook returns a hook sending the entries to h, to use a slog.Handler
+// as an output of a Logger with AddHook. The fields of the entries are
+// passed as attributes sorted by key, the logrus.Fields values as groups.
+func SlogHook(h slog.Handler) logrus.Hook {
+	return slogHook{h}
+}
+
+type slogHook struct {
+	h slog.Handler
+}
+
+func (h slogHook) kind() string {
+	return "slog"
+}
+
+func (h slogHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h slogHook) Fire(entry *logrus.Entry) error {
+	level := SlogLevel(Level(entry.Level))
+	ctx := context.Background()
+	if !h.h.Enabled(ctx, level) {
+		return nil
+	}
+	t := entry.Time
+	if t.IsZero() {
+		t = time.Now()
+	}
+	r := slog.NewRecord(t, level, entry.Message, 0)
+
+	r.AddAttrs(slogAttrs(entry.Data)...)
+	return h.h.Handle(ctx, r)
+}
+
+// slogAttrs converts fields to attributes sorted by key.
+func slogAttrs(fields map[string]interface{}) []slog.Attr {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	attrs := make([]slog.Attr, len(keys))
+	for i, k := range keys {
+		attrs[i] = slogAttr(k, fields[k])
+	}
+	return attrs
+}
+
+// slogAttr converts a field to an attribute, keeping the type of the
+// typed fields.
+func slogAttr(key string, v interface{}) slog.Attr {
+	switch v := v.(type) {
+	case Field:
+		return slog.Any(key, v.Value())
+	case Stacktrace:
+		return slog.String(key, v.String())
+	case logrus.Fields:
+		return slog.Attr{Key: key, Value: slog.GroupValue(slogAttrs(v)...)}
+	}
+	return slog.Any(key, v)
+}
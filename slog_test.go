@@ -0,0 +1,192 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.21
+// +build go1.21
+
+package log
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestSlogLevels(t *testing.T) {
+	for _, level := range levelSlice {
+		if got := LevelFromSlog(SlogLevel(level)); got != level {
+			t.Fatalf("%s maps back to %s", level, got)
+		}
+	}
+	if LevelFromSlog(slog.LevelInfo+2) != InfoLevel || LevelFromSlog(slog.LevelDebug-1) != TraceLevel {
+		t.Fatal("intermediate slog levels are not mapped to the least severe level")
+	}
+}
+
+func TestSlogHandler(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLogger(&buf)
+	l.(logger).entry.Logger.Formatter = &logrus.JSONFormatter{}
+	l.(logger).core.setLevel(TraceLevel)
+
+	sl := slog.New(NewSlogHandler(l.With("service", "api"))).
+		With("user", "bob").WithGroup("req").With("id", 7)
+	source := nextLine()
+	sl.Log(context.Background(), SlogLevelTrace, "handled", "status", 200, slog.Group("timing", "total", time.Second))
+
+	var data map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &data); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"msg":     "handled",
+		"service": "api",
+		"user":    "bob",
+		"source":  source,
+	}
+	for k, v := range want {
+		if data[k] != v {
+			t.Fatalf("%s = %v, want %v in %s", k, data[k], v, buf.String())
+		}
+	}
+	req := map[string]interface{}{
+		"id":     float64(7),
+		"status": float64(200),
+		"timing": map[string]interface{}{"total": float64(time.Second)},
+	}
+	if !reflect.DeepEqual(data["req"], req) {
+		t.Fatalf("req = %v, want %v in %s", data["req"], req, buf.String())
+	}
+
+	buf.Reset()
+	l.(logger).core.setLevel(InfoLevel)
+	sl.Debug("disabled")
+	if buf.Len() != 0 {
+		t.Fatalf("disabled record written: %q", buf.String())
+	}
+}
+
+func TestSlogHandlerContext(t *testing.T) {
+	restoreExtractors(t)
+	RegisterContextKey(testCtxKey("request_id"), "request_id")
+	RegisterContextKey(testCtxKey("user"), "user")
+
+	var buf bytes.Buffer
+	bound := context.WithValue(context.Background(), testCtxKey("request_id"), "abc123")
+	sl := slog.New(NewSlogHandler(NewLogger(&buf).WithContext(bound)))
+
+	sl.InfoContext(context.Background(), "background")
+	if !strings.Contains(buf.String(), "request_id=abc123") {
+		t.Fatalf("bound context fields lost in %q", buf.String())
+	}
+
+	buf.Reset()
+	sl.InfoContext(context.WithValue(context.Background(), testCtxKey("user"), "bob"), "merged")
+	if out := buf.String(); !strings.Contains(out, "request_id=abc123") || !strings.Contains(out, "user=bob") {
+		t.Fatalf("context fields not merged in %q", out)
+	}
+}
+
+func TestSlogHandlerOtherLogger(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLogger(&buf)
+
+	sl := slog.New(NewSlogHandler(otherLogger{l}))
+	sl.Warn("forwarded", "k", "v")
+	sl.Debug("disabled")
+	out := buf.String()
+	if !strings.Contains(out, "msg=forwarded") || !strings.Contains(out, "k=v") {
+		t.Fatalf("unexpected output %q", out)
+	}
+	if strings.Contains(out, "disabled") {
+		t.Fatalf("disabled record forwarded in %q", out)
+	}
+}
+
+func TestSlogHandlerStacktrace(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLogger(&buf)
+	l.SetStacktraceLevel(ErrorLevel)
+
+	slog.New(NewSlogHandler(l)).Error("failed")
+	if !strings.Contains(buf.String(), `stacktrace="github.com/lwhile/log.TestSlogHandlerStacktrace\n\t`) {
+		t.Fatalf("unexpected stack trace in %q", buf.String())
+	}
+}
+
+func TestSlogHook(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLogger(&bytes.Buffer{})
+	info, err := l.AddHook(SlogHook(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: SlogLevelTrace})), TraceLevel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Kind != "slog" {
+		t.Fatalf("unexpected kind %q", info.Kind)
+	}
+	l.(logger).core.setLevel(TraceLevel)
+
+	l.WithError(errors.New("boom")).Log(TraceLevel, "traced", Int("attempt", 3), Duration("took", time.Millisecond))
+	var data map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &data); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"level":   "DEBUG-4",
+		"msg":     "traced",
+		"attempt": float64(3),
+		"took":    float64(time.Millisecond),
+		"error":   "boom",
+	}
+	for k, v := range want {
+		if data[k] != v {
+			t.Fatalf("%s = %v, want %v in %s", k, data[k], v, buf.String())
+		}
+	}
+	if !strings.HasPrefix(data["source"].(string), "slog_test.go:") {
+		t.Fatalf("unexpected source in %s", buf.String())
+	}
+}
+
+func TestSlogGroupsRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLogger(&bytes.Buffer{})
+	if _, err := l.AddHook(SlogHook(slog.NewJSONHandler(&buf, nil)), InfoLevel); err != nil {
+		t.Fatal(err)
+	}
+
+	sl := slog.New(NewSlogHandler(l)).WithGroup("req").With("id", 7)
+	sl.Info("first", "status", 200, slog.Group("user", "name", "bob"))
+	sl.Info("second", "status", 404)
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("unexpected records %q", lines)
+	}
+	for i, want := range []string{
+		`"req":{"id":7,"status":200,"user":{"name":"bob"}}`,
+		`"req":{"id":7,"status":404}`,
+	} {
+		if !strings.Contains(lines[i], want) {
+			t.Fatalf("%s not found in %s", want, lines[i])
+		}
+	}
+}
@@ -0,0 +1,224 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"runtime"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/evalphobia/logrus_sentry"
+	"github.com/getsentry/raven-go"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// StacktraceKey is the field holding the Stacktrace of an entry.
+const StacktraceKey = "stacktrace"
+
+// maxStackDepth bounds the number of frames captured for an entry.
+const maxStackDepth = 64
+
+// Stacktrace is the call stack stored in the stacktrace field of the
+// entries logged at or above the stacktrace level, innermost frame first.
+// It holds program counters as returned by runtime.Callers.
+type Stacktrace []uintptr
+
+// SetStacktraceLevel makes the standard logger capture a stack trace for
+// the entries logged at level or above. Fatal and Panic entries always
+// carry one, which is the default.
+func SetStacktraceLevel(level Level) {
+	baseLogger.SetStacktraceLevel(level)
+}
+
+// SetStacktraceLevel makes the logger capture a stack trace for the
+// entries logged at level or above, see the package function.
+func (l logger) SetStacktraceLevel(level Level) {
+	atomic.StoreUint32(&l.core.stack, uint32(level))
+}
+
+// stackEnabled reports whether entries at level carry a stack trace.
+func (c *core) stackEnabled(level Level) bool {
+	return level <= FatalLevel || level <= Level(atomic.LoadUint32(&c.stack))
+}
+
+// stacktrace returns the stack to attach to entry: the innermost stack
+// of its pkg/errors error if any, the stack of the goroutine skip frames
+// above the caller of stacktrace otherwise.
+func stacktrace(entry *logrus.Entry, skip int) Stacktrace {
+	if err, ok := entry.Data[logrus.ErrorKey].(error); ok {
+		if st := errorStack(err); st != nil {
+			stack := make(Stacktrace, len(st))
+			for i, f := range st {
+				stack[i] = uintptr(f)
+			}
+			return stack
+		}
+	}
+	var pcs [maxStackDepth]uintptr
+	// skip runtime.Callers and stacktrace itself
+	n := runtime.Callers(skip+2, pcs[:])
+	return append(Stacktrace(nil), pcs[:n]...)
+}
+
+// errorStack returns the innermost pkg/errors stack of the errors
+// wrapped by err.
+func errorStack(err error) errors.StackTrace {
+	var stack errors.StackTrace
+	for e := err; e != nil; e = unwrap(e) {
+		if st, ok := e.(stackTracer); ok {
+			stack = st.StackTrace()
+		}
+	}
+	return stack
+}
+
+// Frames returns the frames of the stack, innermost first.
+func (s Stacktrace) Frames() []runtime.Frame {
+	frames := make([]runtime.Frame, 0, len(s))
+	it := runtime.CallersFrames(s)
+	for {
+		frame, more := it.Next()
+		if frame.Function != "runtime.goexit" {
+			frames = append(frames, frame)
+		}
+		if !more {
+			return frames
+		}
+	}
+}
+
+// String formats the stack like the traces of a Go panic, a function
+// followed by its file and line on every other line.
+func (s Stacktrace) String() string {
+	var b bytes.Buffer
+	s.appendText(&b)
+	return b.String()
+}
+
+func (s Stacktrace) appendText(b *bytes.Buffer) {
+	for i, f := range s.Frames() {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(f.Function)
+		b.WriteString("\n\t")
+		b.WriteString(f.File)
+		b.WriteByte(':')
+		b.WriteString(strconv.Itoa(f.Line))
+	}
+}
+
+type stackFrame struct {
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+}
+
+// MarshalJSON encodes the stack as a list of frames, innermost first.
+func (s Stacktrace) MarshalJSON() ([]byte, error) {
+	frames := s.Frames()
+	out := make([]stackFrame, len(frames))
+	for i, f := range frames {
+		out[i] = stackFrame{Function: f.Function, File: f.File, Line: f.Line}
+	}
+	return json.Marshal(out)
+}
+
+// ravenStacktrace converts the stack for Sentry, oldest frame first.
+func (s Stacktrace) ravenStacktrace(context int, inAppPrefixes []string) *raven.Stacktrace {
+	frames := s.Frames()
+	st := &raven.Stacktrace{Frames: make([]*raven.StacktraceFrame, 0, len(frames))}
+	for i := len(frames) - 1; i >= 0; i-- {
+		f := frames[i]
+		if frame := raven.NewStacktraceFrame(f.PC, f.File, f.Line, context, inAppPrefixes); frame != nil {
+			st.Frames = append(st.Frames, frame)
+		}
+	}
+	return st
+}
+
+// sentryHook sends the entries carrying a stacktrace with the stack hook,
+// which reports the stack of the error it is given, and the others with
+// the plain hook which does not capture stacks on its own.
+type sentryHook struct {
+	*logrus_sentry.SentryHook
+
+	// stack shares the client of the plain hook, it is nil for hooks
+	// created outside of this package
+	stack *logrus_sentry.SentryHook
+}
+
+func newSentryHook(client *raven.Client, levels []logrus.Level, async bool) sentryHook {
+	newHook := logrus_sentry.NewWithClientSentryHook
+	if async {
+		newHook = logrus_sentry.NewAsyncWithClientSentryHook
+	}
+	plain, _ := newHook(client, levels)
+	stack, _ := newHook(client, levels)
+	stack.StacktraceConfiguration.Enable = true
+	stack.StacktraceConfiguration.Level = logrus.Level(TraceLevel)
+	return sentryHook{SentryHook: plain, stack: stack}
+}
+
+func (h sentryHook) Fire(entry *logrus.Entry) error {
+	st, ok := entry.Data[StacktraceKey].(Stacktrace)
+	if !ok || h.stack == nil {
+		return h.SentryHook.Fire(entry)
+	}
+
+	data := make(logrus.Fields, len(entry.Data))
+	for k, v := range entry.Data {
+		data[k] = v
+	}
+	delete(data, StacktraceKey)
+	serr := &stackError{msg: entry.Message, stack: st.ravenStacktrace(h.stack.StacktraceConfiguration.Context, h.stack.StacktraceConfiguration.InAppPrefixes)}
+	if err, ok := data[logrus.ErrorKey].(error); ok {
+		serr.msg = err.Error()
+		data[logrus.ErrorKey] = &stackCauseError{stackError: serr, cause: err}
+	} else {
+		data[logrus.ErrorKey] = serr
+	}
+	e := *entry
+	e.Data = data
+	return h.stack.Fire(&e)
+}
+
+func (h sentryHook) Flush() {
+	h.SentryHook.Flush()
+	if h.stack != nil {
+		h.stack.Flush()
+	}
+}
+
+// stackError hands a stack to the Sentry hook, which reads it from the
+// error of the entry.
+type stackError struct {
+	msg   string
+	stack *raven.Stacktrace
+}
+
+func (e *stackError) Error() string                    { return e.msg }
+func (e *stackError) GetStacktrace() *raven.Stacktrace { return e.stack }
+
+// stackCauseError is a stackError for an entry with an error, Sentry
+// reports the type of its cause.
+type stackCauseError struct {
+	*stackError
+	cause error
+}
+
+func (e *stackCauseError) Cause() error { return e.cause }
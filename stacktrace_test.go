@@ -0,0 +1,103 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	pkgerrors "github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+func TestStacktraceLevel(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLogger(&buf)
+	l.(logger).entry.Logger.Formatter = &logrus.JSONFormatter{}
+
+	l.Error("no stack")
+	if strings.Contains(buf.String(), `"stacktrace"`) {
+		t.Fatalf("unexpected stack trace in %q", buf.String())
+	}
+
+	buf.Reset()
+	l.SetStacktraceLevel(ErrorLevel)
+	l.Error("stack")
+	var data map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &data); err != nil {
+		t.Fatal(err)
+	}
+	frames, ok := data[StacktraceKey].([]interface{})
+	if !ok || len(frames) == 0 {
+		t.Fatalf("no stack trace in %q", buf.String())
+	}
+	top := frames[0].(map[string]interface{})
+	if top["function"] != "github.com/lwhile/log.TestStacktraceLevel" || !strings.HasSuffix(top["file"].(string), "stacktrace_test.go") {
+		t.Fatalf("unexpected top frame %v", top)
+	}
+
+	buf.Reset()
+	l.Warn("no stack")
+	if strings.Contains(buf.String(), `"stacktrace"`) {
+		t.Fatalf("unexpected stack trace in %q", buf.String())
+	}
+}
+
+func TestStacktracePanic(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLogger(&buf)
+	l.(logger).entry.Logger.Formatter = PrefixedFormatter
+
+	func() {
+		defer func() { recover() }()
+		l.Panic("boom")
+	}()
+	lines := strings.Split(buf.String(), "\n")
+	if len(lines) < 3 || !strings.HasSuffix(lines[0], " boom") {
+		t.Fatalf("unexpected output %q", buf.String())
+	}
+	if !strings.HasPrefix(lines[1], "github.com/lwhile/log.TestStacktracePanic") || !strings.Contains(lines[2], "stacktrace_test.go:") {
+		t.Fatalf("unexpected stack trace %q", buf.String())
+	}
+}
+
+func newStackError() error {
+	return pkgerrors.New("failed")
+}
+
+func TestStacktraceFromError(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLogger(&buf)
+	l.SetStacktraceLevel(ErrorLevel)
+
+	l.WithError(pkgerrors.Wrap(newStackError(), "wrapped")).Error("error")
+	if !strings.Contains(buf.String(), `stacktrace="github.com/lwhile/log.newStackError\n\t`) {
+		t.Fatalf("error stack not used in %q", buf.String())
+	}
+}
+
+func TestSentryStacktrace(t *testing.T) {
+	entry := &logrus.Entry{Data: logrus.Fields{}}
+	st := stacktrace(entry, 0)
+
+	rs := st.ravenStacktrace(0, nil)
+	if len(rs.Frames) == 0 {
+		t.Fatal("no frames")
+	}
+	if top := rs.Frames[len(rs.Frames)-1]; top.Function != "TestSentryStacktrace" || top.Module != "github.com/lwhile/log" {
+		t.Fatalf("unexpected top frame %+v", top)
+	}
+}
@@ -0,0 +1,198 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// maxWriterLine is the length after which a line written to a Writer is
+// logged even though it does not end yet.
+const maxWriterLine = 64 << 10
+
+// RedirectStdLog makes the logger of the standard library log package
+// write to the standard logger at level. Its prefix is cleared and the
+// source field is the file and line of the log.Print call. The returned
+// function restores the previous output, prefix and flags.
+func RedirectStdLog(level Level) func() {
+	return redirectStdLog(baseLogger, level)
+}
+
+func redirectStdLog(l logger, level Level) func() {
+	flags, prefix, out := log.Flags(), log.Prefix(), log.Writer()
+	log.SetFlags(log.Lshortfile)
+	log.SetPrefix("")
+	log.SetOutput(&stdLogWriter{l: l, level: level})
+	return func() {
+		log.SetOutput(out)
+		log.SetPrefix(prefix)
+		log.SetFlags(flags)
+	}
+}
+
+// stdLogWriter logs the lines written by a log.Logger with the Lshortfile
+// flag, a message with several lines is logged as one entry per line like
+// with Writer. With SourceShort, the source reported by the flag replaces
+// the one found on the stack, it is right for log.Output as well.
+type stdLogWriter struct {
+	l     logger
+	level Level
+}
+
+func (w *stdLogWriter) Write(b []byte) (int, error) {
+	if !w.l.IsEnabled(w.level) {
+		return len(b), nil
+	}
+	lw := &lineWriter{l: w.l, level: w.level}
+	mode := w.l.core.sourceMode()
+	if mode&sourcePathMask != SourceNone {
+		// skip Write
+		lw.source, lw.function = caller(1+stdLogSkip()+w.l.skip, mode)
+	}
+	msg := string(b)
+	if source, rest, ok := splitShortfile(msg); ok {
+		msg = rest
+		if mode&sourcePathMask == SourceShort {
+			lw.source = source
+		}
+	}
+	lw.Write([]byte(msg))
+	lw.Close()
+	return len(b), nil
+}
+
+// logPackage is the import path of this package.
+var logPackage = reflect.TypeOf(logger{}).PkgPath()
+
+// stdLogSkip returns the number of frames between the caller of
+// stdLogSkip and the code that called a log.Logger, that is the first
+// frame outside the log package of the standard library and this
+// package.
+func stdLogSkip() int {
+	var pcs [16]uintptr
+	// skip runtime.Callers, stdLogSkip and its caller
+	n := runtime.Callers(3, pcs[:])
+	frames := runtime.CallersFrames(pcs[:n])
+	skip := 0
+	for {
+		frame, more := frames.Next()
+		pkg := funcPackage(frame.Function)
+		if pkg != "log" && pkg != logPackage || !more {
+			return skip
+		}
+		skip++
+	}
+}
+
+// splitShortfile splits the "file.go:12: " header added by the Lshortfile
+// flag from the message.
+func splitShortfile(s string) (source, msg string, ok bool) {
+	end := strings.Index(s, ": ")
+	if end < 0 {
+		return "", s, false
+	}
+	colon := strings.LastIndexByte(s[:end], ':')
+	if colon <= 0 || colon == end-1 {
+		return "", s, false
+	}
+	for _, c := range s[colon+1 : end] {
+		if c < '0' || c > '9' {
+			return "", s, false
+		}
+	}
+	return s[:end], s[end+2:], true
+}
+
+// Writer returns a writer logging every line written to it at level on the
+// standard logger, see Logger.Writer.
+func Writer(level Level) io.WriteCloser {
+	return baseLogger.writer(level)
+}
+
+// Writer returns a writer logging every line written to it at level, to
+// capture the output of a command or a library. Empty lines are skipped.
+// The source field of the entries is where Writer was called. Close logs
+// the last line if it does not end with a newline.
+func (l logger) Writer(level Level) io.WriteCloser {
+	return l.writer(level)
+}
+
+func (l logger) writer(level Level) io.WriteCloser {
+	w := &lineWriter{l: l, level: level}
+	if mode := l.core.sourceMode(); mode&sourcePathMask != SourceNone {
+		// skip writer and Writer
+		w.source, w.function = caller(2+l.skip, mode)
+	}
+	return w
+}
+
+type lineWriter struct {
+	l                logger
+	level            Level
+	source, function string
+
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (w *lineWriter) Write(b []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	n := len(b)
+	for len(b) > 0 {
+		i := bytes.IndexByte(b, '\n')
+		if i < 0 {
+			w.buf.Write(b)
+			if w.buf.Len() >= maxWriterLine {
+				w.flush()
+			}
+			break
+		}
+		w.buf.Write(b[:i])
+		w.flush()
+		b = b[i+1:]
+	}
+	return n, nil
+}
+
+func (w *lineWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.flush()
+	return nil
+}
+
+// flush logs the buffered line. w.mu must be held.
+func (w *lineWriter) flush() {
+	line := strings.TrimSuffix(w.buf.String(), "\r")
+	w.buf.Reset()
+	if line == "" || !w.l.IsEnabled(w.level) {
+		return
+	}
+	var fields []Field
+	if w.source != "" {
+		fields = append(fields, Any("source", w.source))
+	}
+	if w.function != "" {
+		fields = append(fields, Any(FuncKey, w.function))
+	}
+	w.l.log(w.level, line, fields)
+}
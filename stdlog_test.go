@@ -0,0 +1,93 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestRedirectStdLog(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLogger(&buf)
+
+	log.SetPrefix("legacy: ")
+	log.SetFlags(log.LstdFlags)
+	undo := redirectStdLog(l.(logger), WarnLevel)
+	source := nextLine()
+	log.Printf("disk %d%% full", 90)
+	log.Print("first\r\n\nsecond")
+	undo()
+
+	out := buf.String()
+	for _, want := range []string{"level=warning", `msg="disk 90% full"`, `source="` + source + `"`} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("%s not found in %q", want, out)
+		}
+	}
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	if len(lines) != 3 || !strings.Contains(lines[1], "msg=first") || !strings.Contains(lines[2], "msg=second") {
+		t.Fatalf("multi-line message not split in %q", lines)
+	}
+	if strings.Contains(out, "legacy") {
+		t.Fatalf("prefix not stripped in %q", out)
+	}
+	if log.Prefix() != "legacy: " || log.Flags() != log.LstdFlags {
+		t.Fatalf("undo did not restore prefix %q and flags %d", log.Prefix(), log.Flags())
+	}
+	log.SetPrefix("")
+}
+
+func TestSplitShortfile(t *testing.T) {
+	for _, tc := range []struct {
+		in, source, msg string
+		ok              bool
+	}{
+		{"main.go:12: started", "main.go:12", "started", true},
+		{"main.go:12: key: value", "main.go:12", "key: value", true},
+		{"key: value", "", "key: value", false},
+		{"a:b: c", "", "a:b: c", false},
+	} {
+		source, msg, ok := splitShortfile(tc.in)
+		if source != tc.source || msg != tc.msg || ok != tc.ok {
+			t.Errorf("splitShortfile(%q) = %q, %q, %v", tc.in, source, msg, ok)
+		}
+	}
+}
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLogger(&buf)
+	source := nextLine()
+	w := l.Writer(ErrorLevel)
+
+	w.Write([]byte("first\r\n\nsec"))
+	w.Write([]byte("ond\nunterminated"))
+	if n := strings.Count(buf.String(), "\n"); n != 2 {
+		t.Fatalf("%d entries before Close in %q", n, buf.String())
+	}
+	w.Close()
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("unexpected entries %q", lines)
+	}
+	for i, want := range []string{"msg=first", "msg=second", "msg=unterminated"} {
+		if !strings.Contains(lines[i], want) || !strings.Contains(lines[i], "level=error") || !strings.Contains(lines[i], `source="`+source+`"`) {
+			t.Fatalf("%s not found in %q", want, lines[i])
+		}
+	}
+}
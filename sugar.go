@@ -0,0 +1,153 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"fmt"
+)
+
+// SugarErrorKey is the field under which problems with the keysAndValues
+// passed to the *w methods are reported.
+const SugarErrorKey = "logw_error"
+
+// sweeten turns alternating keys and values into fields. A non-string key is
+// converted with fmt.Sprint and a dangling key is logged with a nil value;
+// both problems are described under SugarErrorKey instead of panicking.
+func sweeten(keysAndValues []interface{}) []Field {
+	fields := make([]Field, 0, len(keysAndValues)/2+1)
+	var problems []string
+	for i := 0; i < len(keysAndValues); i += 2 {
+		key, ok := keysAndValues[i].(string)
+		if !ok {
+			key = fmt.Sprint(keysAndValues[i])
+			problems = append(problems, fmt.Sprintf("non-string key %v (%T) at position %d", keysAndValues[i], keysAndValues[i], i))
+		}
+		if i+1 >= len(keysAndValues) {
+			problems = append(problems, fmt.Sprintf("odd number of arguments, key %q has no value", key))
+			fields = append(fields, Any(key, nil))
+			break
+		}
+		fields = append(fields, Any(key, keysAndValues[i+1]))
+	}
+	if len(problems) > 0 {
+		fields = append(fields, Any(SugarErrorKey, problems))
+	}
+	return fields
+}
+
+// Tracew logs a message with alternating keys and values at level Trace.
+func (l logger) Tracew(msg string, keysAndValues ...interface{}) {
+	if l.IsEnabled(TraceLevel) {
+		l.log(TraceLevel, msg, sweeten(keysAndValues))
+	}
+}
+
+// Debugw logs a message with alternating keys and values at level Debug.
+func (l logger) Debugw(msg string, keysAndValues ...interface{}) {
+	if l.IsEnabled(DebugLevel) {
+		l.log(DebugLevel, msg, sweeten(keysAndValues))
+	}
+}
+
+// Infow logs a message with alternating keys and values at level Info.
+func (l logger) Infow(msg string, keysAndValues ...interface{}) {
+	if l.IsEnabled(InfoLevel) {
+		l.log(InfoLevel, msg, sweeten(keysAndValues))
+	}
+}
+
+// Warnw logs a message with alternating keys and values at level Warn.
+func (l logger) Warnw(msg string, keysAndValues ...interface{}) {
+	if l.IsEnabled(WarnLevel) {
+		l.log(WarnLevel, msg, sweeten(keysAndValues))
+	}
+}
+
+// Errorw logs a message with alternating keys and values at level Error.
+func (l logger) Errorw(msg string, keysAndValues ...interface{}) {
+	if l.IsEnabled(ErrorLevel) {
+		l.log(ErrorLevel, msg, sweeten(keysAndValues))
+	}
+}
+
+// Fatalw logs a message with alternating keys and values at level Fatal.
+func (l logger) Fatalw(msg string, keysAndValues ...interface{}) {
+	if l.IsEnabled(FatalLevel) {
+		l.log(FatalLevel, msg, sweeten(keysAndValues))
+	} else {
+		l.core.fatalExit()
+	}
+}
+
+// Panicw logs a message with alternating keys and values at level Panic,
+// then panics with the message.
+func (l logger) Panicw(msg string, keysAndValues ...interface{}) {
+	if l.IsEnabled(PanicLevel) {
+		l.log(PanicLevel, msg, sweeten(keysAndValues))
+	}
+	panic(msg)
+}
+
+// Tracew logs a message with alternating keys and values at level Trace on the standard logger.
+func Tracew(msg string, keysAndValues ...interface{}) {
+	if baseLogger.IsEnabled(TraceLevel) {
+		baseLogger.log(TraceLevel, msg, sweeten(keysAndValues))
+	}
+}
+
+// Debugw logs a message with alternating keys and values at level Debug on the standard logger.
+func Debugw(msg string, keysAndValues ...interface{}) {
+	if baseLogger.IsEnabled(DebugLevel) {
+		baseLogger.log(DebugLevel, msg, sweeten(keysAndValues))
+	}
+}
+
+// Infow logs a message with alternating keys and values at level Info on the standard logger.
+func Infow(msg string, keysAndValues ...interface{}) {
+	if baseLogger.IsEnabled(InfoLevel) {
+		baseLogger.log(InfoLevel, msg, sweeten(keysAndValues))
+	}
+}
+
+// Warnw logs a message with alternating keys and values at level Warn on the standard logger.
+func Warnw(msg string, keysAndValues ...interface{}) {
+	if baseLogger.IsEnabled(WarnLevel) {
+		baseLogger.log(WarnLevel, msg, sweeten(keysAndValues))
+	}
+}
+
+// Errorw logs a message with alternating keys and values at level Error on the standard logger.
+func Errorw(msg string, keysAndValues ...interface{}) {
+	if baseLogger.IsEnabled(ErrorLevel) {
+		baseLogger.log(ErrorLevel, msg, sweeten(keysAndValues))
+	}
+}
+
+// Fatalw logs a message with alternating keys and values at level Fatal on the standard logger.
+func Fatalw(msg string, keysAndValues ...interface{}) {
+	if baseLogger.IsEnabled(FatalLevel) {
+		baseLogger.log(FatalLevel, msg, sweeten(keysAndValues))
+	} else {
+		baseLogger.core.fatalExit()
+	}
+}
+
+// Panicw logs a message with alternating keys and values at level Panic on the standard logger,
+// then panics with the message.
+func Panicw(msg string, keysAndValues ...interface{}) {
+	if baseLogger.IsEnabled(PanicLevel) {
+		baseLogger.log(PanicLevel, msg, sweeten(keysAndValues))
+	}
+	panic(msg)
+}
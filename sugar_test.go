@@ -0,0 +1,54 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSweeten(t *testing.T) {
+	fields := sweeten([]interface{}{"user", "bob", "count", 3})
+	if len(fields) != 2 || fields[0].Key != "user" || fields[0].Value() != "bob" || fields[1].Value() != 3 {
+		t.Fatalf("unexpected fields %v", fields)
+	}
+
+	fields = sweeten([]interface{}{"user", "bob", 42, "x", "dangling"})
+	if len(fields) != 4 {
+		t.Fatalf("len(fields) = %d != 4: %v", len(fields), fields)
+	}
+	if fields[1].Key != "42" || fields[1].Value() != "x" {
+		t.Fatalf("fields[1] = %v", fields[1])
+	}
+	if fields[2].Key != "dangling" || fields[2].Value() != nil {
+		t.Fatalf("fields[2] = %v", fields[2])
+	}
+	problems, _ := fields[3].Value().([]string)
+	if fields[3].Key != SugarErrorKey || len(problems) != 2 {
+		t.Fatalf("unexpected problems %v", fields[3])
+	}
+}
+
+func TestInfow(t *testing.T) {
+	var buf bytes.Buffer
+	NewLogger(&buf).Infow("user logged in", "user", "bob", "attempts", 2)
+
+	out := buf.String()
+	for _, want := range []string{`msg="user logged in"`, "user=bob", "attempts=2", `source="sugar_test.go:`} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("%q not found in %q", want, out)
+		}
+	}
+}
@@ -58,7 +58,11 @@ func newSyslogger(appname string, facility string, fmter logrus.Formatter) (*sys
 		return nil, err
 	}
 	out, err := syslog.New(priority, appname)
-	_, isJSON := fmter.(*logrus.JSONFormatter)
+	inner := fmter
+	if t, ok := inner.(traceFormatter); ok {
+		inner = t.Formatter
+	}
+	_, isJSON := inner.(*logrus.JSONFormatter)
 	if isJSON {
 		// add cee tag to json formatted syslogs
 		prefixTag = []byte("@cee:")
@@ -112,7 +116,7 @@ func (s *syslogger) Format(e *logrus.Entry) ([]byte, error) {
 		err = s.out.Warning(line)
 	case logrus.InfoLevel:
 		err = s.out.Info(line)
-	case logrus.DebugLevel:
+	case logrus.DebugLevel, logrus.Level(TraceLevel):
 		err = s.out.Debug(line)
 	default:
 		err = s.out.Notice(line)